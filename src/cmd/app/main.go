@@ -28,11 +28,29 @@ func init() {
 		panic(err)
 	}
 
-	err = db.DB.AutoMigrate(&models.User{}, &models.Space{}, &models.SpaceWhiteboard{}, &models.KanbanTasks{})
+	// user_spaces carries the member's role, so it needs its own model
+	if err := db.DB.SetupJoinTable(&models.Space{}, "Members", &models.UserSpace{}); err != nil {
+		panic(fmt.Sprintf("Error setting up user_spaces: %v", err))
+	}
+	if err := db.DB.SetupJoinTable(&models.User{}, "Spaces", &models.UserSpace{}); err != nil {
+		panic(fmt.Sprintf("Error setting up user_spaces: %v", err))
+	}
+
+	err = db.DB.AutoMigrate(&models.User{}, &models.Space{}, &models.SpaceInvite{}, &models.SpaceWhiteboard{}, &models.SpaceChatMessage{}, &models.SpaceChatReaction{}, &models.DirectMessage{}, &models.SpaceZone{}, &models.KanbanTasks{}, &models.KanbanColumn{}, &models.KanbanComment{}, &models.KanbanActivity{}, &models.KanbanChecklistItem{}, &models.KanbanLabel{})
 	if err != nil {
 		log.Error("Error migrating database", "error", err.Error())
 		panic(fmt.Sprintf("Error migrating database: %v", err))
 	}
+
+	// Memberships created before roles existed default to member, the creator of each space becomes its owner
+	err = db.DB.Exec(`UPDATE user_spaces SET role = ? FROM spaces
+		WHERE spaces.id = user_spaces.space_id AND spaces.created_by = user_spaces.user_id
+		AND NOT EXISTS (SELECT 1 FROM user_spaces owners WHERE owners.space_id = user_spaces.space_id AND owners.role = ?)`,
+		models.SpaceRoleOwner, models.SpaceRoleOwner).Error
+	if err != nil {
+		log.Error("Error backfilling space owners", "error", err.Error())
+		panic(fmt.Sprintf("Error backfilling space owners: %v", err))
+	}
 }
 
 func main() {
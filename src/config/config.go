@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -60,6 +62,34 @@ func GetCORSConfig() *cors.Config {
 	}
 }
 
+// GetSpaceRetention is how long deleted spaces can be restored before they are purged.
+func GetSpaceRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("SPACE_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// GetProximityRadius is how far, in map pixels, proximity chat carries.
+func GetProximityRadius() float64 {
+	radius, err := strconv.ParseFloat(os.Getenv("PROXIMITY_RADIUS"), 64)
+	if err != nil || radius <= 0 {
+		radius = 200
+	}
+	return radius
+}
+
+// GetMaxPlayerSpeed is how fast, in map pixels per second, players may move.
+// Clients walk at 200, the default leaves room for timing differences.
+func GetMaxPlayerSpeed() float64 {
+	speed, err := strconv.ParseFloat(os.Getenv("MAX_PLAYER_SPEED"), 64)
+	if err != nil || speed <= 0 {
+		speed = 250
+	}
+	return speed
+}
+
 func GetRedisConfig() *redis.Options {
 	redisHost := os.Getenv("REDIS_HOST")
 	redisPort := os.Getenv("REDIS_PORT")
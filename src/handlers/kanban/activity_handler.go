@@ -0,0 +1,130 @@
+package kanban
+
+import (
+	"strconv"
+
+	"github.com/bhav-07/haven/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultActivityLimit = 50
+	maxActivityLimit     = 200
+)
+
+func activityHandlers(route fiber.Router, db *gorm.DB) {
+	// Space-wide feed, newest first. Pass the last seen id as ?before= to page back.
+	route.Get("/activity", boardAccess(db, models.SpacePermissionView), func(c *fiber.Ctx) error {
+		spaceId, err := strconv.ParseUint(c.Query("spaceID"), 10, 64)
+		if err != nil {
+			log.Warn("Unable to convert string to uint")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to convert string to uint",
+			})
+		}
+
+		return listActivity(c, db, db.Where("space_id = ?", spaceId))
+	})
+
+	route.Get("/:taskID/history", taskAccess(db, models.SpacePermissionView), func(c *fiber.Ctx) error {
+		// The history of a deleted task stays readable
+		task, err := getTask(db.Unscoped(), c.Params("taskID"))
+		if err != nil {
+			return taskLookupError(c, err)
+		}
+
+		return listActivity(c, db, db.Where("task_id = ?", task.ID))
+	})
+}
+
+func listActivity(c *fiber.Ctx, db *gorm.DB, query *gorm.DB) error {
+	limit := c.QueryInt("limit", defaultActivityLimit)
+	if limit <= 0 || limit > maxActivityLimit {
+		limit = defaultActivityLimit
+	}
+
+	if before := c.Query("before"); before != "" {
+		beforeId, err := strconv.ParseUint(before, 10, 64)
+		if err != nil {
+			log.Warn("Unable to convert string to uint")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to convert string to uint",
+			})
+		}
+		query = query.Where("id < ?", beforeId)
+	}
+
+	var activities []models.KanbanActivity
+	if err := query.Preload("Actor", func(db *gorm.DB) *gorm.DB {
+		return db.Select("users.id", "users.nickname", "users.character")
+	}).
+		Order("id DESC").
+		Limit(limit).
+		Find(&activities).Error; err != nil {
+		log.Warnf("Error fetching activity: %v", err.Error())
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Failed to fetch activity",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   activities,
+	})
+}
+
+// recordActivity appends entries to the task audit trail. It runs in the same
+// transaction as the change it describes so the log never drifts from the data.
+func recordActivity(tx *gorm.DB, activities ...models.KanbanActivity) error {
+	if len(activities) == 0 {
+		return nil
+	}
+	return tx.Omit("Actor").Create(&activities).Error
+}
+
+// taskChanges lists one activity entry per field that differs between the two
+// versions of a task. Status and rank changes get their own actions.
+func taskChanges(before, after models.KanbanTasks, actorId uint) []models.KanbanActivity {
+	var activities []models.KanbanActivity
+
+	add := func(action models.TaskAction, field, oldValue, newValue string) {
+		if oldValue == newValue {
+			return
+		}
+		activities = append(activities, models.KanbanActivity{
+			SpaceID:  after.SpaceID,
+			TaskID:   after.ID,
+			ActorID:  actorId,
+			Action:   action,
+			Field:    field,
+			OldValue: oldValue,
+			NewValue: newValue,
+		})
+	}
+
+	add(models.TaskActionUpdated, "title", before.Title, after.Title)
+	add(models.TaskActionUpdated, "description", before.Description, after.Description)
+	add(models.TaskActionUpdated, "priority", string(before.Priority), string(after.Priority))
+	add(models.TaskActionUpdated, "due_date", before.DueDate, after.DueDate)
+	add(models.TaskActionUpdated, "assignee_id", formatOptionalID(before.AssigneeID), formatOptionalID(after.AssigneeID))
+	add(models.TaskActionUpdated, "parent_id", formatOptionalID(before.ParentID), formatOptionalID(after.ParentID))
+	add(models.TaskActionStatusChanged, "status", string(before.Status), string(after.Status))
+
+	if before.Status == after.Status {
+		add(models.TaskActionMoved, "rank", before.Rank, after.Rank)
+	}
+
+	return activities
+}
+
+func formatOptionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
@@ -0,0 +1,391 @@
+package kanban
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/bhav-07/haven/models"
+	"github.com/bhav-07/haven/redis"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+func checklistHandlers(route fiber.Router, db *gorm.DB, kanbanServer *redis.KanbanServer) {
+	route.Get("/:taskID/checklist", taskAccess(db, models.SpacePermissionView), func(c *fiber.Ctx) error {
+		task, err := getTask(db, c.Params("taskID"))
+		if err != nil {
+			return taskLookupError(c, err)
+		}
+
+		var items []models.KanbanChecklistItem
+		if err := db.Where("task_id = ?", task.ID).Order("position").Find(&items).Error; err != nil {
+			log.Warnf("Error fetching checklist: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to fetch checklist",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"status": "success",
+			"data":   items,
+		})
+	})
+
+	route.Post("/:taskID/checklist", taskAccess(db, models.SpacePermissionEditTasks), func(c *fiber.Ctx) error {
+		task, err := getTask(db, c.Params("taskID"))
+		if err != nil {
+			return taskLookupError(c, err)
+		}
+
+		type CreateItemRequest struct {
+			Text string `json:"text"`
+		}
+		req := new(CreateItemRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid request body",
+			})
+		}
+
+		item := models.KanbanChecklistItem{
+			TaskID: task.ID,
+			Text:   strings.TrimSpace(req.Text),
+		}
+		if item.Text == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Checklist item cannot be empty",
+			})
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var count int64
+			if err := tx.Model(&models.KanbanChecklistItem{}).Where("task_id = ?", task.ID).Count(&count).Error; err != nil {
+				return err
+			}
+
+			item.Position = int(count)
+			return tx.Create(&item).Error
+		})
+		if err != nil {
+			log.Warnf("Error creating checklist item: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to create checklist item",
+			})
+		}
+
+		publishChecklist(db, kanbanServer, task)
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Checklist item created successfully",
+			"data":    item,
+		})
+	})
+
+	route.Patch("/:taskID/checklist/:itemID", taskAccess(db, models.SpacePermissionEditTasks), func(c *fiber.Ctx) error {
+		task, err := getTask(db, c.Params("taskID"))
+		if err != nil {
+			return taskLookupError(c, err)
+		}
+
+		item, err := getChecklistItem(db, task.ID, c.Params("itemID"))
+		if err != nil {
+			return checklistLookupError(c, err)
+		}
+
+		type UpdateItemRequest struct {
+			Text     *string `json:"text"`
+			Done     *bool   `json:"done"`
+			Position *int    `json:"position"`
+		}
+		req := new(UpdateItemRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid request body",
+			})
+		}
+
+		var fields []string
+		if req.Text != nil {
+			item.Text = strings.TrimSpace(*req.Text)
+			if item.Text == "" {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"status": "error",
+					"error":  "Checklist item cannot be empty",
+				})
+			}
+			fields = append(fields, "text")
+		}
+		if req.Done != nil {
+			item.Done = *req.Done
+			fields = append(fields, "done")
+		}
+
+		if len(fields) == 0 && req.Position == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "No fields to update",
+			})
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if req.Position != nil && *req.Position != item.Position {
+				if err := moveChecklistItem(tx, &item, *req.Position); err != nil {
+					return err
+				}
+			}
+
+			if len(fields) == 0 {
+				return nil
+			}
+			return tx.Model(&item).Select(fields).Updates(&item).Error
+		})
+		if err != nil {
+			log.Warnf("Error updating checklist item: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to update checklist item",
+			})
+		}
+
+		publishChecklist(db, kanbanServer, task)
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Checklist item updated successfully",
+			"data":    item,
+		})
+	})
+
+	route.Delete("/:taskID/checklist/:itemID", taskAccess(db, models.SpacePermissionEditTasks), func(c *fiber.Ctx) error {
+		task, err := getTask(db, c.Params("taskID"))
+		if err != nil {
+			return taskLookupError(c, err)
+		}
+
+		item, err := getChecklistItem(db, task.ID, c.Params("itemID"))
+		if err != nil {
+			return checklistLookupError(c, err)
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Delete(&item).Error; err != nil {
+				return err
+			}
+
+			return tx.Model(&models.KanbanChecklistItem{}).
+				Where("task_id = ? AND position > ?", task.ID, item.Position).
+				Update("position", gorm.Expr("position - 1")).Error
+		})
+		if err != nil {
+			log.Warnf("Error deleting checklist item: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to delete checklist item",
+			})
+		}
+
+		publishChecklist(db, kanbanServer, task)
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Checklist item deleted successfully",
+		})
+	})
+
+	route.Get("/:taskID/subtasks", taskAccess(db, models.SpacePermissionView), func(c *fiber.Ctx) error {
+		task, err := getTask(db, c.Params("taskID"))
+		if err != nil {
+			return taskLookupError(c, err)
+		}
+
+		var subtasks []models.KanbanTasks
+		if err := withTaskRelations(db).Where("parent_id = ?", task.ID).Order(rankOrder).Find(&subtasks).Error; err != nil {
+			log.Warnf("Error fetching subtasks: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to fetch subtasks",
+			})
+		}
+
+		if err := attachProgress(db, subtasks); err != nil {
+			log.Warnf("Error computing task progress: %v", err.Error())
+		}
+
+		return c.JSON(fiber.Map{
+			"status": "success",
+			"data":   subtasks,
+		})
+	})
+}
+
+// moveChecklistItem shifts the items between the old and new position so
+// positions stay contiguous.
+func moveChecklistItem(tx *gorm.DB, item *models.KanbanChecklistItem, position int) error {
+	var count int64
+	if err := tx.Model(&models.KanbanChecklistItem{}).Where("task_id = ?", item.TaskID).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if position < 0 {
+		position = 0
+	}
+	if position >= int(count) {
+		position = int(count) - 1
+	}
+
+	siblings := tx.Model(&models.KanbanChecklistItem{}).Where("task_id = ? AND id <> ?", item.TaskID, item.ID)
+	if position < item.Position {
+		if err := siblings.Where("position >= ? AND position < ?", position, item.Position).
+			Update("position", gorm.Expr("position + 1")).Error; err != nil {
+			return err
+		}
+	} else {
+		if err := siblings.Where("position > ? AND position <= ?", item.Position, position).
+			Update("position", gorm.Expr("position - 1")).Error; err != nil {
+			return err
+		}
+	}
+
+	item.Position = position
+	return tx.Model(item).Update("position", position).Error
+}
+
+// validateParent makes sure the parent lives in the same space and that
+// linking it would not create a cycle.
+func validateParent(db *gorm.DB, task models.KanbanTasks, parentId uint) error {
+	if parentId == task.ID {
+		return errors.New("A task cannot be its own parent")
+	}
+
+	var parent models.KanbanTasks
+	if err := db.Select("id", "space_id", "parent_id").First(&parent, parentId).Error; err != nil || parent.SpaceID != task.SpaceID {
+		return errors.New("Parent task not found in this space")
+	}
+
+	for ancestor := parent.ParentID; ancestor != nil; {
+		if *ancestor == task.ID {
+			return errors.New("A task cannot be nested under one of its own subtasks")
+		}
+
+		var next models.KanbanTasks
+		if err := db.Select("id", "parent_id").First(&next, *ancestor).Error; err != nil {
+			break
+		}
+		ancestor = next.ParentID
+	}
+
+	return nil
+}
+
+// attachProgress fills in checklist and subtask completion for the given tasks.
+// A subtask counts as done when it sits in a column flagged as done.
+func attachProgress(db *gorm.DB, tasks []models.KanbanTasks) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	taskIds := make([]uint, len(tasks))
+	for i, task := range tasks {
+		taskIds[i] = task.ID
+	}
+
+	type progressRow struct {
+		TaskID uint
+		Done   int64
+		Total  int64
+	}
+
+	var checklistRows []progressRow
+	if err := db.Model(&models.KanbanChecklistItem{}).
+		Select("task_id, COUNT(*) FILTER (WHERE done) AS done, COUNT(*) AS total").
+		Where("task_id IN ?", taskIds).
+		Group("task_id").
+		Scan(&checklistRows).Error; err != nil {
+		return err
+	}
+
+	var subtaskRows []progressRow
+	if err := db.Model(&models.KanbanTasks{}).
+		Select("kanban_tasks.parent_id AS task_id, COUNT(*) FILTER (WHERE kanban_columns.is_done) AS done, COUNT(*) AS total").
+		Joins("LEFT JOIN kanban_columns ON kanban_columns.space_id = kanban_tasks.space_id AND kanban_columns.name = kanban_tasks.status AND kanban_columns.deleted_at IS NULL").
+		Where("kanban_tasks.parent_id IN ?", taskIds).
+		Group("kanban_tasks.parent_id").
+		Scan(&subtaskRows).Error; err != nil {
+		return err
+	}
+
+	progress := make(map[uint]*models.TaskProgress, len(tasks))
+	for i := range tasks {
+		tasks[i].Progress = &models.TaskProgress{}
+		progress[tasks[i].ID] = tasks[i].Progress
+	}
+
+	for _, row := range checklistRows {
+		progress[row.TaskID].ChecklistDone = row.Done
+		progress[row.TaskID].ChecklistTotal = row.Total
+	}
+	for _, row := range subtaskRows {
+		progress[row.TaskID].SubtasksDone = row.Done
+		progress[row.TaskID].SubtasksTotal = row.Total
+	}
+
+	return nil
+}
+
+func publishChecklist(db *gorm.DB, kanbanServer *redis.KanbanServer, task models.KanbanTasks) {
+	var items []models.KanbanChecklistItem
+	if err := db.Where("task_id = ?", task.ID).Order("position").Find(&items).Error; err != nil {
+		log.Warnf("Error fetching checklist: %v", err.Error())
+		return
+	}
+
+	if err := kanbanServer.Publish(task.SpaceID, "task_checklist_updated", fiber.Map{
+		"task_id":   task.ID,
+		"checklist": items,
+	}); err != nil {
+		log.Errorf("Failed to publish kanban event: %v", err)
+	}
+}
+
+func getChecklistItem(db *gorm.DB, taskId uint, itemIdString string) (models.KanbanChecklistItem, error) {
+	var item models.KanbanChecklistItem
+
+	itemId, err := strconv.ParseUint(itemIdString, 10, 64)
+	if err != nil {
+		return item, err
+	}
+
+	err = db.Where("task_id = ?", taskId).First(&item, itemId).Error
+	return item, err
+}
+
+func checklistLookupError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Checklist item not found",
+		})
+	}
+
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		log.Warn("Unable to convert string to uint")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Unable to convert string to uint",
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"status": "error",
+		"error":  "Failed to load checklist item",
+	})
+}
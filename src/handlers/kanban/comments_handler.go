@@ -0,0 +1,323 @@
+package kanban
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bhav-07/haven/models"
+	"github.com/bhav-07/haven/redis"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+func commentHandlers(route fiber.Router, db *gorm.DB) {
+	route.Get("/:taskID/comments", taskAccess(db, models.SpacePermissionView), func(c *fiber.Ctx) error {
+		task, err := getTask(db, c.Params("taskID"))
+		if err != nil {
+			return taskLookupError(c, err)
+		}
+
+		// Deleted comments stay in the thread as tombstones so replies keep their context
+		var comments []models.KanbanComment
+		if err := withCommentRelations(db).Unscoped().
+			Where("task_id = ?", task.ID).
+			Order("created_at").
+			Find(&comments).Error; err != nil {
+			log.Warnf("Error fetching comments: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to fetch comments",
+			})
+		}
+
+		for i := range comments {
+			if comments[i].DeletedAt.Valid {
+				comments[i].Body = ""
+				comments[i].Mentions = nil
+			}
+		}
+
+		return c.JSON(fiber.Map{
+			"status": "success",
+			"data":   comments,
+		})
+	})
+
+	route.Post("/:taskID/comments", taskAccess(db, models.SpacePermissionEditTasks), func(c *fiber.Ctx) error {
+		userId, ok := c.Locals("userId").(uint)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to get user details. Please login again",
+			})
+		}
+
+		task, err := getTask(db, c.Params("taskID"))
+		if err != nil {
+			return taskLookupError(c, err)
+		}
+
+		body, err := parseCommentBody(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  err.Error(),
+			})
+		}
+
+		mentions, err := findMentions(db, task.SpaceID, body)
+		if err != nil {
+			log.Warnf("Error resolving mentions: %v", err.Error())
+		}
+
+		comment := models.KanbanComment{
+			TaskID:   task.ID,
+			AuthorID: userId,
+			Body:     body,
+			Mentions: mentions,
+		}
+
+		if err := db.Omit("Mentions.*").Create(&comment).Error; err != nil {
+			log.Warnf("Error creating comment: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to create comment",
+			})
+		}
+
+		return publishComment(c, db, task, comment.ID, "task_comment_created", "Comment created successfully")
+	})
+
+	route.Patch("/:taskID/comments/:commentID", taskAccess(db, models.SpacePermissionEditTasks), func(c *fiber.Ctx) error {
+		userId, ok := c.Locals("userId").(uint)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to get user details. Please login again",
+			})
+		}
+
+		task, err := getTask(db, c.Params("taskID"))
+		if err != nil {
+			return taskLookupError(c, err)
+		}
+
+		comment, err := getComment(db, task.ID, c.Params("commentID"))
+		if err != nil {
+			return commentLookupError(c, err)
+		}
+
+		if comment.AuthorID != userId {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status": "error",
+				"error":  "You can only edit your own comments",
+			})
+		}
+
+		body, err := parseCommentBody(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  err.Error(),
+			})
+		}
+
+		mentions, err := findMentions(db, task.SpaceID, body)
+		if err != nil {
+			log.Warnf("Error resolving mentions: %v", err.Error())
+		}
+
+		now := time.Now()
+		comment.Body = body
+		comment.EditedAt = &now
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&comment).Select("body", "edited_at").Updates(&comment).Error; err != nil {
+				return err
+			}
+			return tx.Model(&comment).Omit("Mentions.*").Association("Mentions").Replace(mentions)
+		})
+		if err != nil {
+			log.Warnf("Error updating comment: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to update comment",
+			})
+		}
+
+		return publishComment(c, db, task, comment.ID, "task_comment_updated", "Comment updated successfully")
+	})
+
+	route.Delete("/:taskID/comments/:commentID", taskAccess(db, models.SpacePermissionEditTasks), func(c *fiber.Ctx) error {
+		userId, ok := c.Locals("userId").(uint)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to get user details. Please login again",
+			})
+		}
+
+		task, err := getTask(db, c.Params("taskID"))
+		if err != nil {
+			return taskLookupError(c, err)
+		}
+
+		comment, err := getComment(db, task.ID, c.Params("commentID"))
+		if err != nil {
+			return commentLookupError(c, err)
+		}
+
+		if comment.AuthorID != userId {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status": "error",
+				"error":  "You can only delete your own comments",
+			})
+		}
+
+		if err := db.Delete(&comment).Error; err != nil {
+			log.Warnf("Error deleting comment: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to delete comment",
+			})
+		}
+
+		if err := redis.PublishSpaceEvent(redis.RedisClient, task.SpaceID, "task_comment_deleted", map[string]interface{}{
+			"task_id":    task.ID,
+			"comment_id": comment.ID,
+		}); err != nil {
+			log.Errorf("Failed to publish comment event: %v", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Comment deleted successfully",
+		})
+	})
+}
+
+func withCommentRelations(db *gorm.DB) *gorm.DB {
+	selectUser := func(db *gorm.DB) *gorm.DB {
+		return db.Select("users.id", "users.nickname", "users.character")
+	}
+
+	return db.Preload("Author", selectUser).Preload("Mentions", selectUser)
+}
+
+func parseCommentBody(c *fiber.Ctx) (string, error) {
+	type CommentRequest struct {
+		Body string `json:"body"`
+	}
+	req := new(CommentRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return "", errors.New("Invalid request body")
+	}
+
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return "", errors.New("Comment cannot be empty")
+	}
+
+	return body, nil
+}
+
+// findMentions returns the space members whose nickname appears as @nickname
+// in the body. Nicknames may contain spaces, so each member's nickname is
+// matched as a whole instead of splitting the body into words.
+func findMentions(db *gorm.DB, spaceId uint, body string) ([]models.User, error) {
+	if !strings.Contains(body, "@") {
+		return nil, nil
+	}
+
+	var members []models.User
+	if err := db.Joins("JOIN user_spaces ON user_spaces.user_id = users.id AND user_spaces.deleted_at IS NULL").
+		Where("user_spaces.space_id = ?", spaceId).
+		Select("users.id", "users.nickname").
+		Find(&members).Error; err != nil {
+		return nil, err
+	}
+
+	var mentions []models.User
+	for _, member := range members {
+		if member.Nickname == "" {
+			continue
+		}
+
+		pattern := `(?i)(^|[^\pL\pN_])@` + regexp.QuoteMeta(member.Nickname) + `($|[^\pL\pN_])`
+		if regexp.MustCompile(pattern).MatchString(body) {
+			mentions = append(mentions, member)
+		}
+	}
+
+	return mentions, nil
+}
+
+func publishComment(c *fiber.Ctx, db *gorm.DB, task models.KanbanTasks, commentId uint, eventType string, message string) error {
+	var comment models.KanbanComment
+	if err := withCommentRelations(db).First(&comment, commentId).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Failed to load comment",
+		})
+	}
+
+	mentionedIds := make([]string, 0, len(comment.Mentions))
+	for _, user := range comment.Mentions {
+		mentionedIds = append(mentionedIds, strconv.FormatUint(uint64(user.ID), 10))
+	}
+
+	if err := redis.PublishSpaceEvent(redis.RedisClient, task.SpaceID, eventType, map[string]interface{}{
+		"task_id":       task.ID,
+		"task_title":    task.Title,
+		"comment":       comment,
+		"mentioned_ids": mentionedIds,
+	}); err != nil {
+		log.Errorf("Failed to publish comment event: %v", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": message,
+		"data":    comment,
+	})
+}
+
+func getComment(db *gorm.DB, taskId uint, commentIdString string) (models.KanbanComment, error) {
+	var comment models.KanbanComment
+
+	commentId, err := strconv.ParseUint(commentIdString, 10, 64)
+	if err != nil {
+		return comment, err
+	}
+
+	err = db.Where("task_id = ?", taskId).First(&comment, commentId).Error
+	return comment, err
+}
+
+func commentLookupError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Comment not found",
+		})
+	}
+
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		log.Warn("Unable to convert string to uint")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Unable to convert string to uint",
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"status": "error",
+		"error":  "Failed to load comment",
+	})
+}
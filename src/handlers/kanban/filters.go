@@ -0,0 +1,104 @@
+package kanban
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/bhav-07/haven/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// applyTaskFilters narrows a task query with the filters supported by GET /kanban:
+//
+//	assignee    user ID, "me" or "none"
+//	created_by  user ID or "me"
+//	watcher     user ID or "me"
+//	label       comma separated label IDs, matches tasks with any of them
+//	priority    comma separated priorities
+//	status      comma separated workflow column names
+//	due_from    YYYY-MM-DD, inclusive
+//	due_to      YYYY-MM-DD, inclusive
+//	q           case insensitive search on title and description
+func applyTaskFilters(c *fiber.Ctx, query *gorm.DB, userId uint) (*gorm.DB, error) {
+	if assignee := c.Query("assignee"); assignee == "none" {
+		query = query.Where("kanban_tasks.assignee_id IS NULL")
+	} else if assignee != "" {
+		assigneeId, err := resolveUserFilter(assignee, userId)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid assignee filter")
+		}
+		query = query.Where("kanban_tasks.assignee_id = ?", assigneeId)
+	}
+
+	if creator := c.Query("created_by"); creator != "" {
+		creatorId, err := resolveUserFilter(creator, userId)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid created_by filter")
+		}
+		query = query.Where("kanban_tasks.created_by = ?", creatorId)
+	}
+
+	if watcher := c.Query("watcher"); watcher != "" {
+		watcherId, err := resolveUserFilter(watcher, userId)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid watcher filter")
+		}
+		query = query.Where("EXISTS (SELECT 1 FROM kanban_task_watchers WHERE kanban_task_watchers.kanban_tasks_id = kanban_tasks.id AND kanban_task_watchers.user_id = ?)", watcherId)
+	}
+
+	if labels := splitFilter(c.Query("label")); len(labels) > 0 {
+		labelIds := make([]uint64, 0, len(labels))
+		for _, label := range labels {
+			labelId, err := strconv.ParseUint(label, 10, 64)
+			if err != nil {
+				return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid label filter")
+			}
+			labelIds = append(labelIds, labelId)
+		}
+		query = query.Where("EXISTS (SELECT 1 FROM kanban_task_labels WHERE kanban_task_labels.kanban_tasks_id = kanban_tasks.id AND kanban_task_labels.kanban_label_id IN ?)", labelIds)
+	}
+
+	if priorities := splitFilter(c.Query("priority")); len(priorities) > 0 {
+		query = query.Where("kanban_tasks.priority IN ?", priorities)
+	}
+
+	if statuses := splitFilter(c.Query("status")); len(statuses) > 0 {
+		query = query.Where("kanban_tasks.status IN ?", statuses)
+	}
+
+	// Due dates are stored as YYYY-MM-DD, so they compare correctly as text once
+	// the filters are written the same way
+	if dueFrom := c.Query("due_from"); dueFrom != "" {
+		parsed, err := parseDueDate(dueFrom)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid due_from filter. Use the YYYY-MM-DD format")
+		}
+		query = query.Where("kanban_tasks.due_date <> '' AND kanban_tasks.due_date >= ?", parsed.Format(dueDateLayout))
+	}
+
+	if dueTo := c.Query("due_to"); dueTo != "" {
+		parsed, err := parseDueDate(dueTo)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid due_to filter. Use the YYYY-MM-DD format")
+		}
+		query = query.Where("kanban_tasks.due_date <> '' AND kanban_tasks.due_date <= ?", parsed.Format(dueDateLayout))
+	}
+
+	if search := strings.TrimSpace(c.Query("q")); search != "" {
+		pattern := "%" + utils.EscapeLike(search) + "%"
+		query = query.Where("(kanban_tasks.title ILIKE ? OR kanban_tasks.description ILIKE ?)", pattern, pattern)
+	}
+
+	return query, nil
+}
+
+func splitFilter(value string) []string {
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
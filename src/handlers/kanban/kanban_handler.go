@@ -1,18 +1,41 @@
 package kanban
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/bhav-07/haven/middleware"
 	"github.com/bhav-07/haven/models"
+	"github.com/bhav-07/haven/redis"
+	"github.com/bhav-07/haven/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func KanbanHandlers(route fiber.Router, db *gorm.DB) {
-	route.Post("/", func(c *fiber.Ctx) error {
+
+	kanbanServer := redis.NewKanbanServer()
+
+	workflowHandlers(route, db, kanbanServer)
+
+	moveHandlers(route, db, kanbanServer)
+
+	route.Post("/", boardAccess(db, models.SpacePermissionEditTasks), func(c *fiber.Ctx) error {
+		userId, ok := c.Locals("userId").(uint)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to get user details. Please login again",
+			})
+		}
+
 		spaceId := c.Query("spaceID")
 
 		spaceIdUINT, err := strconv.ParseUint(spaceId, 10, 64)
@@ -27,8 +50,6 @@ func KanbanHandlers(route fiber.Router, db *gorm.DB) {
 
 		newTask := new(models.KanbanTasks)
 
-		newTask.SpaceID = uint(spaceIdUINT)
-
 		if err := json.Unmarshal(c.Body(), &newTask); err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"status": "error",
@@ -36,27 +57,94 @@ func KanbanHandlers(route fiber.Router, db *gorm.DB) {
 			})
 		}
 
-		if err := db.Create(&newTask).Error; err != nil {
-			log.Warn("Error creating Task: %v", err.Error())
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		newTask.Model = gorm.Model{}
+		newTask.SpaceID = uint(spaceIdUINT)
+		newTask.CreatedBy = userId
+		newTask.Creator = nil
+		newTask.Assignee = nil
+		newTask.Watchers = nil
+		newTask.Progress = nil
+		// Labels are attached through POST /:taskID/labels/:labelID, which checks their space
+		newTask.Labels = nil
+
+		if err := validateTask(newTask); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"status": "error",
-				"error":  "Failed to create task",
+				"error":  err.Error(),
+			})
+		}
+
+		if newTask.AssigneeID != nil {
+			if err := validateSpaceMember(db, newTask.SpaceID, *newTask.AssigneeID); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"status": "error",
+					"error":  err.Error(),
+				})
+			}
+		}
+
+		if newTask.ParentID != nil {
+			if err := validateParent(db, *newTask, *newTask.ParentID); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"status": "error",
+					"error":  err.Error(),
+				})
+			}
+		}
+
+		var reranked map[uint]string
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if _, err := resolveTaskColumn(tx, newTask); err != nil {
+				return err
+			}
+
+			if reranked, err = appendToColumn(tx, newTask); err != nil {
+				return err
+			}
+
+			if err := tx.Create(&newTask).Error; err != nil {
+				return err
+			}
+
+			watcherIds := []uint{userId}
+			if newTask.AssigneeID != nil {
+				watcherIds = append(watcherIds, *newTask.AssigneeID)
+			}
+			if err := addWatchers(tx, newTask.ID, watcherIds...); err != nil {
+				return err
+			}
+
+			return recordActivity(tx, models.KanbanActivity{
+				SpaceID:  newTask.SpaceID,
+				TaskID:   newTask.ID,
+				ActorID:  userId,
+				Action:   models.TaskActionCreated,
+				NewValue: newTask.Title,
 			})
+		})
+		if err != nil {
+			return boardError(c, err, "Failed to create task")
+		}
+
+		publishReranked(kanbanServer, newTask.SpaceID, newTask.Status, reranked)
+
+		if err := withTaskRelations(db).First(&newTask, newTask.ID).Error; err != nil {
+			log.Warn("Error loading Task: %v", err.Error())
 		}
 
-		// taskJSON, _ := json.Marshal(fiber.Map{
-		// 	"type": "create",
-		// 	"task": newTask,
-		// })
-		// clientManager.BroadcastToSpace(newTask.SpaceID, taskJSON)
+		if err := kanbanServer.Publish(newTask.SpaceID, "task_created", newTask); err != nil {
+			log.Errorf("Failed to publish kanban event: %v", err)
+		}
 
 		return c.Status(fiber.StatusOK).JSON(fiber.Map{
 			"status":  "success",
 			"message": "Task created successfully",
+			"data":    newTask,
 		})
 	})
 
-	route.Get("/", func(c *fiber.Ctx) error {
+	route.Get("/", boardAccess(db, models.SpacePermissionView), func(c *fiber.Ctx) error {
 		spaceId := c.Query("spaceID")
 
 		spaceIdUINT, err := strconv.ParseUint(spaceId, 10, 64)
@@ -69,23 +157,124 @@ func KanbanHandlers(route fiber.Router, db *gorm.DB) {
 			})
 		}
 
-		tasks := new([]models.KanbanTasks)
+		userId, _ := c.Locals("userId").(uint)
+
+		sortName := c.Query("sort", "board")
+		sort, ok := taskSorts[sortName]
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid sort. Valid sorts are: board, due_date, priority, created",
+			})
+		}
+		descending := c.Query("order") == "desc"
+
+		limit := c.QueryInt("limit", defaultTaskLimit)
+		if limit <= 0 || limit > maxTaskLimit {
+			limit = defaultTaskLimit
+		}
+
+		// Every query starts from the board join so filters and sorts can use the column
+		boardQuery := func() *gorm.DB {
+			query := db.Model(&models.KanbanTasks{}).
+				Joins("LEFT JOIN kanban_columns ON kanban_columns.space_id = kanban_tasks.space_id AND kanban_columns.name = kanban_tasks.status AND kanban_columns.deleted_at IS NULL").
+				Where("kanban_tasks.space_id = ?", spaceIdUINT)
+
+			// Tasks that have been sitting in a done column for longer than N days are left out
+			if days := c.QueryInt("hide_done_older_than", 0); days > 0 {
+				query = query.Where("NOT (COALESCE(kanban_columns.is_done, false) AND kanban_tasks.updated_at < ?)", time.Now().AddDate(0, 0, -days))
+			}
+
+			return query
+		}
+
+		query, err := applyTaskFilters(c, boardQuery(), userId)
+		if err != nil {
+			return boardError(c, err, "Failed to fetch tasks")
+		}
+
+		if cursor := c.Query("cursor"); cursor != "" {
+			values, err := decodeTaskCursor(cursor, sort)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"status": "error",
+					"error":  "Invalid cursor",
+				})
+			}
+			query = sort.after(query, values, descending)
+		}
+
+		tasks := []models.KanbanTasks{}
 
-		if err := db.Where(&models.KanbanTasks{SpaceID: uint(spaceIdUINT)}).Find(&tasks).Error; err != nil {
-			log.Warn("Error creating Task: %v", err.Error())
+		// One extra row tells whether there is a next page
+		if err := sort.orderBy(withTaskRelations(query), descending).Limit(limit + 1).Find(&tasks).Error; err != nil {
+			log.Warnf("Error fetching Tasks: %v", err.Error())
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"status": "error",
-				"error":  "Failed to create task",
+				"error":  "Failed to fetch tasks",
 			})
 		}
 
+		var nextCursor *string
+		if len(tasks) > limit {
+			tasks = tasks[:limit]
+
+			var columns []models.KanbanColumn
+			if err := db.Where("space_id = ?", spaceIdUINT).Find(&columns).Error; err != nil {
+				log.Warnf("Error loading workflow: %v", err.Error())
+			}
+			positions := make(map[models.TaskStatus]int, len(columns))
+			for _, column := range columns {
+				positions[column.Name] = column.Position
+			}
+
+			cursor := encodeTaskCursor(sort.values(tasks[len(tasks)-1], positions))
+			nextCursor = &cursor
+		}
+
+		if err := attachProgress(db, tasks); err != nil {
+			log.Warnf("Error computing task progress: %v", err.Error())
+		}
+
+		// Totals per status ignore the cursor so the board can show full column counts
+		countQuery, _ := applyTaskFilters(c, boardQuery(), userId)
+
+		type statusCount struct {
+			Status models.TaskStatus
+			Count  int64
+		}
+		var statusCounts []statusCount
+		if err := countQuery.Select("kanban_tasks.status AS status, COUNT(*) AS count").
+			Group("kanban_tasks.status").
+			Scan(&statusCounts).Error; err != nil {
+			log.Warnf("Error counting Tasks: %v", err.Error())
+		}
+
+		counts := make(map[models.TaskStatus]int64, len(statusCounts))
+		var total int64
+		for _, row := range statusCounts {
+			counts[row.Status] = row.Count
+			total += row.Count
+		}
+
 		return c.JSON(fiber.Map{
-			"status": "success",
-			"data":   tasks,
+			"status":      "success",
+			"data":        tasks,
+			"next_cursor": nextCursor,
+			"counts":      counts,
+			"total":       total,
 		})
 	})
 
-	route.Patch("/", func(c *fiber.Ctx) error {
+	route.Patch("/", boardAccess(db, models.SpacePermissionEditTasks), func(c *fiber.Ctx) error {
+		userId, ok := c.Locals("userId").(uint)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to get user details. Please login again",
+			})
+		}
+
 		spaceId := c.Query("spaceID")
 		taskId := c.Query("taskID")
 
@@ -99,10 +288,21 @@ func KanbanHandlers(route fiber.Router, db *gorm.DB) {
 				"error":  "Unable to convert string to uint",
 			})
 		}
-		type UpdateTaskStatusRequest struct {
-			NewStatus string `json:"new_status"`
+		// Every field is optional, only the ones present in the body are updated.
+		// new_status is kept for clients that only move cards between columns.
+		type UpdateTaskRequest struct {
+			Title       *string `json:"title"`
+			Description *string `json:"description"`
+			Status      *string `json:"status"`
+			NewStatus   *string `json:"new_status"`
+			Priority    *string `json:"priority"`
+			DueDate     *string `json:"due_date"`
+			// null unassigns the task, an absent field leaves it untouched
+			AssigneeID json.RawMessage `json:"assignee_id"`
+			// null detaches a subtask from its parent
+			ParentID json.RawMessage `json:"parent_id"`
 		}
-		req := new(UpdateTaskStatusRequest)
+		req := new(UpdateTaskRequest)
 
 		if err := c.BodyParser(req); err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -111,31 +311,161 @@ func KanbanHandlers(route fiber.Router, db *gorm.DB) {
 			})
 		}
 
-		fmt.Println(req.NewStatus)
-
-		result := db.Model(&models.KanbanTasks{}).Where("id = ? AND space_id = ?", taskIdUINT, spaceIdUINT).Update("status", req.NewStatus)
-		if result.Error != nil {
+		var task models.KanbanTasks
+		if err := db.Where("id = ? AND space_id = ?", taskIdUINT, spaceIdUINT).First(&task).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"status": "error",
+					"error":  "Task not found",
+				})
+			}
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"status": "error",
 				"error":  "Failed to update task",
 			})
 		}
 
-		if result.RowsAffected == 0 {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		before := task
+		var fields []string
+
+		if req.Title != nil {
+			task.Title = *req.Title
+			fields = append(fields, "title")
+		}
+		if req.Description != nil {
+			task.Description = *req.Description
+			fields = append(fields, "description")
+		}
+		if req.Status == nil {
+			req.Status = req.NewStatus
+		}
+		if req.Status != nil {
+			task.Status = models.TaskStatus(*req.Status)
+			fields = append(fields, "status")
+		}
+		if req.Priority != nil {
+			task.Priority = models.TaskPriority(*req.Priority)
+			fields = append(fields, "priority")
+		}
+		if req.DueDate != nil {
+			task.DueDate = *req.DueDate
+			fields = append(fields, "due_date")
+		}
+
+		if len(req.AssigneeID) > 0 {
+			task.AssigneeID = nil
+			if string(req.AssigneeID) != "null" {
+				var assigneeId uint
+				if err := json.Unmarshal(req.AssigneeID, &assigneeId); err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"status": "error",
+						"error":  "Invalid assignee_id",
+					})
+				}
+				if err := validateSpaceMember(db, task.SpaceID, assigneeId); err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"status": "error",
+						"error":  err.Error(),
+					})
+				}
+				task.AssigneeID = &assigneeId
+			}
+			fields = append(fields, "assignee_id")
+		}
+
+		if len(req.ParentID) > 0 {
+			task.ParentID = nil
+			if string(req.ParentID) != "null" {
+				var parentId uint
+				if err := json.Unmarshal(req.ParentID, &parentId); err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"status": "error",
+						"error":  "Invalid parent_id",
+					})
+				}
+				if err := validateParent(db, task, parentId); err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"status": "error",
+						"error":  err.Error(),
+					})
+				}
+				task.ParentID = &parentId
+			}
+			fields = append(fields, "parent_id")
+		}
+
+		if len(fields) == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "No fields to update",
+			})
+		}
+
+		if err := validateTask(&task); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"status": "error",
-				"error":  "Task found",
+				"error":  err.Error(),
 			})
 		}
 
+		var reranked map[uint]string
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if task.Status != before.Status {
+				if _, err := resolveTaskColumn(tx, &task); err != nil {
+					return err
+				}
+
+				// A card changing column lands at the bottom of the new one
+				var err error
+				if reranked, err = appendToColumn(tx, &task); err != nil {
+					return err
+				}
+				fields = append(fields, "rank")
+			}
+
+			if err := tx.Model(&task).Select(fields).Updates(&task).Error; err != nil {
+				return err
+			}
+			if task.AssigneeID != nil {
+				if err := addWatchers(tx, task.ID, *task.AssigneeID); err != nil {
+					return err
+				}
+			}
+
+			return recordActivity(tx, taskChanges(before, task, userId)...)
+		})
+		if err != nil {
+			return boardError(c, err, "Failed to update task")
+		}
+
+		publishReranked(kanbanServer, task.SpaceID, task.Status, reranked)
+
+		if err := withTaskRelations(db).First(&task, task.ID).Error; err != nil {
+			log.Warn("Error loading Task: %v", err.Error())
+		}
+
+		if err := kanbanServer.Publish(task.SpaceID, "task_updated", task); err != nil {
+			log.Errorf("Failed to publish kanban event: %v", err)
+		}
+
 		return c.JSON(fiber.Map{
 			"status":  "success",
 			"message": "Task updated successfully",
+			"data":    task,
 		})
 
 	})
 
-	route.Delete("/", func(c *fiber.Ctx) error {
+	route.Delete("/", boardAccess(db, models.SpacePermissionEditTasks), func(c *fiber.Ctx) error {
+		userId, ok := c.Locals("userId").(uint)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to get user details. Please login again",
+			})
+		}
+
 		spaceId := c.Query("spaceID")
 		taskId := c.Query("taskID")
 
@@ -150,28 +480,265 @@ func KanbanHandlers(route fiber.Router, db *gorm.DB) {
 			})
 		}
 
-		task := new(models.KanbanTasks)
-
-		task.SpaceID = uint(spaceIdUINT)
-		task.ID = uint(taskIdUINT)
-
-		if err := db.Delete(&task).Error; err != nil {
-			log.Warn("Error creating Task: %v", err.Error())
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"status": "error",
-				"error":  "Failed to create task",
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var task models.KanbanTasks
+			if err := tx.Where("id = ? AND space_id = ?", taskIdUINT, spaceIdUINT).First(&task).Error; err != nil {
+				return err
+			}
+
+			// Members may only delete the tasks they created
+			if task.CreatedBy != userId && !middleware.CurrentSpaceRole(c).Can(models.SpacePermissionDeleteTasks) {
+				return fiber.NewError(fiber.StatusForbidden, "Your role in this space does not allow deleting other people's tasks")
+			}
+
+			if err := tx.Delete(&task).Error; err != nil {
+				return err
+			}
+
+			// Subtasks outlive their parent as top level tasks
+			if err := tx.Model(&models.KanbanTasks{}).Where("parent_id = ?", task.ID).Update("parent_id", nil).Error; err != nil {
+				return err
+			}
+
+			return recordActivity(tx, models.KanbanActivity{
+				SpaceID:  task.SpaceID,
+				TaskID:   task.ID,
+				ActorID:  userId,
+				Action:   models.TaskActionDeleted,
+				OldValue: task.Title,
 			})
+		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"status": "error",
+					"error":  "Task not found",
+				})
+			}
+			return boardError(c, err, "Failed to delete task")
 		}
 
-		// taskJSON, _ := json.Marshal(fiber.Map{
-		// 	"type":   "delete",
-		// 	"taskID": task.ID,
-		// })
-		// clientManager.BroadcastToSpace(task.SpaceID, taskJSON)
+		if err := kanbanServer.Publish(uint(spaceIdUINT), "task_deleted", fiber.Map{"id": taskIdUINT}); err != nil {
+			log.Errorf("Failed to publish kanban event: %v", err)
+		}
 
 		return c.JSON(fiber.Map{
 			"status":  "success",
 			"message": "Task deleted successfully",
 		})
 	})
+
+	// Server-Sent Events stream of task changes for a space. Clients resume
+	// from the Last-Event-ID header (or lastEventId query param) on reconnect.
+	route.Get("/stream", boardAccess(db, models.SpacePermissionView), func(c *fiber.Ctx) error {
+		spaceId := c.Query("spaceID")
+
+		spaceIdUINT, err := strconv.ParseUint(spaceId, 10, 64)
+
+		if err != nil {
+			log.Warn("Unable to convert string to uint")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to convert string to uint",
+			})
+		}
+
+		lastEventID := c.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = c.Query("lastEventId")
+		}
+		if lastEventID != "" && !redis.ValidStreamID(lastEventID) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid Last-Event-ID",
+			})
+		}
+
+		// Subscribe before reading the backlog so nothing published in between is lost
+		events, unsubscribe := kanbanServer.Subscribe(uint(spaceIdUINT))
+
+		var backlog []redis.KanbanEvent
+		if lastEventID != "" {
+			backlog, err = kanbanServer.EventsSince(uint(spaceIdUINT), lastEventID)
+			if err != nil {
+				unsubscribe()
+				log.Errorf("Failed to read kanban event backlog: %v", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"status": "error",
+					"error":  "Failed to resume event stream",
+				})
+			}
+		}
+
+		c.Set("Content-Type", "text/event-stream")
+		c.Set("Cache-Control", "no-cache")
+		c.Set("Connection", "keep-alive")
+		c.Set("X-Accel-Buffering", "no")
+
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer unsubscribe()
+
+			fmt.Fprint(w, "retry: 3000\n\n")
+
+			lastSent := lastEventID
+			for _, event := range backlog {
+				if err := writeSSEEvent(w, event); err != nil {
+					return
+				}
+				lastSent = event.ID
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+
+			heartbeat := time.NewTicker(15 * time.Second)
+			defer heartbeat.Stop()
+
+			for {
+				select {
+				case event, ok := <-events:
+					if !ok {
+						return
+					}
+					if !redis.StreamIDAfter(event.ID, lastSent) {
+						continue
+					}
+					if err := writeSSEEvent(w, event); err != nil {
+						return
+					}
+					lastSent = event.ID
+				case <-heartbeat.C:
+					fmt.Fprint(w, ": ping\n\n")
+				}
+
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		})
+
+		return nil
+	})
+
+	watcherHandlers(route, db, kanbanServer)
+
+	commentHandlers(route, db)
+
+	activityHandlers(route, db)
+
+	checklistHandlers(route, db, kanbanServer)
+
+	labelHandlers(route, db, kanbanServer)
+}
+
+func writeSSEEvent(w *bufio.Writer, event redis.KanbanEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\ndata: %s\n\n", event.ID, data)
+	return err
+}
+
+// validateTask checks the user editable fields of a task and normalizes the
+// due date to YYYY-MM-DD. An empty due date means the task has none. The
+// status is checked against the space workflow by resolveTaskColumn.
+func validateTask(task *models.KanbanTasks) error {
+	task.Title = strings.TrimSpace(task.Title)
+	if task.Title == "" {
+		return errors.New("Title cannot be empty")
+	}
+
+	if task.Priority == "" {
+		task.Priority = models.TaskPriorityMedium
+	}
+	if !task.Priority.IsValid() {
+		return errors.New("Invalid priority. Valid priorities are: HIGH, MEDIUM, LOW")
+	}
+
+	if task.DueDate != "" {
+		dueDate, err := parseDueDate(task.DueDate)
+		if err != nil {
+			return errors.New("Invalid due date. Use the YYYY-MM-DD format")
+		}
+		task.DueDate = dueDate.Format(dueDateLayout)
+	}
+
+	return nil
+}
+
+const dueDateLayout = "2006-01-02"
+
+func parseDueDate(value string) (time.Time, error) {
+	if dueDate, err := time.Parse(dueDateLayout, value); err == nil {
+		return dueDate, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// withTaskRelations preloads the people attached to a task with just enough
+// of their profile for the board to render avatars.
+func withTaskRelations(db *gorm.DB) *gorm.DB {
+	selectUser := func(db *gorm.DB) *gorm.DB {
+		return db.Select("users.id", "users.nickname", "users.character")
+	}
+
+	return db.Preload("Creator", selectUser).
+		Preload("Assignee", selectUser).
+		Preload("Watchers", selectUser).
+		Preload("Labels")
+}
+
+func validateSpaceMember(db *gorm.DB, spaceId uint, userId uint) error {
+	isMember, err := utils.IsSpaceMember(spaceId, userId, db)
+	if err != nil {
+		return errors.New("Unable to verify space membership")
+	}
+	if !isMember {
+		return fmt.Errorf("User %d is not a member of this space", userId)
+	}
+	return nil
+}
+
+func addWatchers(tx *gorm.DB, taskId uint, userIds ...uint) error {
+	rows := make([]map[string]interface{}, 0, len(userIds))
+	for _, userId := range userIds {
+		rows = append(rows, map[string]interface{}{
+			"kanban_tasks_id": taskId,
+			"user_id":         userId,
+		})
+	}
+
+	return tx.Table("kanban_task_watchers").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(rows).Error
+}
+
+func resolveUserFilter(value string, userId uint) (uint, error) {
+	if value == "me" {
+		return userId, nil
+	}
+
+	id, err := strconv.ParseUint(value, 10, 64)
+	return uint(id), err
+}
+
+// Every Kanban route is limited to members of the space it touches whose role
+// holds the permission, the space being found from the spaceID query or from
+// the task, column or label in the path.
+func boardAccess(db *gorm.DB, permission models.SpacePermission) fiber.Handler {
+	return middleware.SpacePermission(db, middleware.SpaceFromQuery("spaceID"), permission)
+}
+
+func taskAccess(db *gorm.DB, permission models.SpacePermission) fiber.Handler {
+	return middleware.SpacePermission(db, middleware.SpaceFromRecord(&models.KanbanTasks{}, "taskID", "Task not found"), permission)
+}
+
+func columnAccess(db *gorm.DB, permission models.SpacePermission) fiber.Handler {
+	return middleware.SpacePermission(db, middleware.SpaceFromRecord(&models.KanbanColumn{}, "columnID", "Column not found"), permission)
+}
+
+func labelAccess(db *gorm.DB, permission models.SpacePermission) fiber.Handler {
+	return middleware.SpacePermission(db, middleware.SpaceFromRecord(&models.KanbanLabel{}, "labelID", "Label not found"), permission)
 }
@@ -0,0 +1,369 @@
+package kanban
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/bhav-07/haven/models"
+	"github.com/bhav-07/haven/redis"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func labelHandlers(route fiber.Router, db *gorm.DB, kanbanServer *redis.KanbanServer) {
+	route.Get("/labels", boardAccess(db, models.SpacePermissionView), func(c *fiber.Ctx) error {
+		spaceId, err := strconv.ParseUint(c.Query("spaceID"), 10, 64)
+		if err != nil {
+			log.Warn("Unable to convert string to uint")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to convert string to uint",
+			})
+		}
+
+		var labels []models.KanbanLabel
+		if err := db.Where("space_id = ?", spaceId).Order("name").Find(&labels).Error; err != nil {
+			log.Warnf("Error fetching labels: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to fetch labels",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"status": "success",
+			"data":   labels,
+		})
+	})
+
+	route.Post("/labels", boardAccess(db, models.SpacePermissionManageBoard), func(c *fiber.Ctx) error {
+		spaceId, err := strconv.ParseUint(c.Query("spaceID"), 10, 64)
+		if err != nil {
+			log.Warn("Unable to convert string to uint")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to convert string to uint",
+			})
+		}
+
+		label := new(models.KanbanLabel)
+		if err := c.BodyParser(label); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid request body",
+			})
+		}
+
+		label.Model = gorm.Model{}
+		label.SpaceID = uint(spaceId)
+		if err := validateLabel(label); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  err.Error(),
+			})
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := ensureUniqueLabel(tx, *label); err != nil {
+				return err
+			}
+			return tx.Create(label).Error
+		})
+		if err != nil {
+			return boardError(c, err, "Failed to create label")
+		}
+
+		publishLabels(db, kanbanServer, label.SpaceID)
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Label created successfully",
+			"data":    label,
+		})
+	})
+
+	route.Patch("/labels/:labelID", labelAccess(db, models.SpacePermissionManageBoard), func(c *fiber.Ctx) error {
+		label, err := getLabel(db, c.Params("labelID"))
+		if err != nil {
+			return labelLookupError(c, err)
+		}
+
+		type UpdateLabelRequest struct {
+			Name  *string `json:"name"`
+			Color *string `json:"color"`
+		}
+		req := new(UpdateLabelRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid request body",
+			})
+		}
+
+		var fields []string
+		if req.Name != nil {
+			label.Name = *req.Name
+			fields = append(fields, "name")
+		}
+		if req.Color != nil {
+			label.Color = *req.Color
+			fields = append(fields, "color")
+		}
+
+		if len(fields) == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "No fields to update",
+			})
+		}
+
+		if err := validateLabel(&label); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  err.Error(),
+			})
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := ensureUniqueLabel(tx, label); err != nil {
+				return err
+			}
+			return tx.Model(&label).Select(fields).Updates(&label).Error
+		})
+		if err != nil {
+			return boardError(c, err, "Failed to update label")
+		}
+
+		publishLabels(db, kanbanServer, label.SpaceID)
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Label updated successfully",
+			"data":    label,
+		})
+	})
+
+	route.Delete("/labels/:labelID", labelAccess(db, models.SpacePermissionManageBoard), func(c *fiber.Ctx) error {
+		label, err := getLabel(db, c.Params("labelID"))
+		if err != nil {
+			return labelLookupError(c, err)
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Table("kanban_task_labels").
+				Where("kanban_label_id = ?", label.ID).
+				Delete(map[string]interface{}{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&label).Error
+		})
+		if err != nil {
+			log.Warnf("Error deleting label: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to delete label",
+			})
+		}
+
+		publishLabels(db, kanbanServer, label.SpaceID)
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Label deleted successfully",
+		})
+	})
+
+	route.Post("/:taskID/labels/:labelID", taskAccess(db, models.SpacePermissionEditTasks), func(c *fiber.Ctx) error {
+		userId, ok := c.Locals("userId").(uint)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to get user details. Please login again",
+			})
+		}
+
+		task, err := getTask(db, c.Params("taskID"))
+		if err != nil {
+			return taskLookupError(c, err)
+		}
+
+		label, err := getLabel(db, c.Params("labelID"))
+		if err != nil || label.SpaceID != task.SpaceID {
+			return labelLookupError(c, gorm.ErrRecordNotFound)
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Table("kanban_task_labels").
+				Clauses(clause.OnConflict{DoNothing: true}).
+				Create(map[string]interface{}{
+					"kanban_tasks_id": task.ID,
+					"kanban_label_id": label.ID,
+				})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+
+			return recordActivity(tx, models.KanbanActivity{
+				SpaceID:  task.SpaceID,
+				TaskID:   task.ID,
+				ActorID:  userId,
+				Action:   models.TaskActionUpdated,
+				Field:    "labels",
+				NewValue: label.Name,
+			})
+		})
+		if err != nil {
+			log.Warnf("Error adding label: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to add label",
+			})
+		}
+
+		return publishTaskLabels(c, db, kanbanServer, task.ID, "Label added successfully")
+	})
+
+	route.Delete("/:taskID/labels/:labelID", taskAccess(db, models.SpacePermissionEditTasks), func(c *fiber.Ctx) error {
+		userId, ok := c.Locals("userId").(uint)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to get user details. Please login again",
+			})
+		}
+
+		task, err := getTask(db, c.Params("taskID"))
+		if err != nil {
+			return taskLookupError(c, err)
+		}
+
+		label, err := getLabel(db, c.Params("labelID"))
+		if err != nil || label.SpaceID != task.SpaceID {
+			return labelLookupError(c, gorm.ErrRecordNotFound)
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Table("kanban_task_labels").
+				Where("kanban_tasks_id = ? AND kanban_label_id = ?", task.ID, label.ID).
+				Delete(map[string]interface{}{})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+
+			return recordActivity(tx, models.KanbanActivity{
+				SpaceID:  task.SpaceID,
+				TaskID:   task.ID,
+				ActorID:  userId,
+				Action:   models.TaskActionUpdated,
+				Field:    "labels",
+				OldValue: label.Name,
+			})
+		})
+		if err != nil {
+			log.Warnf("Error removing label: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to remove label",
+			})
+		}
+
+		return publishTaskLabels(c, db, kanbanServer, task.ID, "Label removed successfully")
+	})
+}
+
+func validateLabel(label *models.KanbanLabel) error {
+	label.Name = strings.TrimSpace(label.Name)
+	if label.Name == "" {
+		return errors.New("Label name cannot be empty")
+	}
+
+	if label.Color == "" {
+		label.Color = "#a3a3a3"
+	}
+
+	return nil
+}
+
+func ensureUniqueLabel(tx *gorm.DB, label models.KanbanLabel) error {
+	var count int64
+	if err := tx.Model(&models.KanbanLabel{}).
+		Where("space_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", label.SpaceID, label.Name, label.ID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return fiber.NewError(fiber.StatusConflict, "A label with this name already exists")
+	}
+	return nil
+}
+
+func publishLabels(db *gorm.DB, kanbanServer *redis.KanbanServer, spaceId uint) {
+	var labels []models.KanbanLabel
+	if err := db.Where("space_id = ?", spaceId).Order("name").Find(&labels).Error; err != nil {
+		log.Warnf("Error fetching labels: %v", err.Error())
+		return
+	}
+
+	if err := kanbanServer.Publish(spaceId, "labels_updated", labels); err != nil {
+		log.Errorf("Failed to publish kanban event: %v", err)
+	}
+}
+
+func publishTaskLabels(c *fiber.Ctx, db *gorm.DB, kanbanServer *redis.KanbanServer, taskId uint, message string) error {
+	var task models.KanbanTasks
+	if err := withTaskRelations(db).First(&task, taskId).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Failed to load task",
+		})
+	}
+
+	if err := kanbanServer.Publish(task.SpaceID, "task_updated", task); err != nil {
+		log.Errorf("Failed to publish kanban event: %v", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": message,
+		"data":    task,
+	})
+}
+
+func getLabel(db *gorm.DB, labelIdString string) (models.KanbanLabel, error) {
+	var label models.KanbanLabel
+
+	labelId, err := strconv.ParseUint(labelIdString, 10, 64)
+	if err != nil {
+		return label, err
+	}
+
+	err = db.First(&label, labelId).Error
+	return label, err
+}
+
+func labelLookupError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Label not found",
+		})
+	}
+
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		log.Warn("Unable to convert string to uint")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Unable to convert string to uint",
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"status": "error",
+		"error":  "Failed to load label",
+	})
+}
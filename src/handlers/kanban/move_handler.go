@@ -0,0 +1,284 @@
+package kanban
+
+import (
+	"github.com/bhav-07/haven/models"
+	"github.com/bhav-07/haven/redis"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+// Ranks longer than this trigger a rebalance of the whole column
+const maxRankLength = 24
+
+// Ranks are compared bytewise regardless of the database collation
+const rankOrder = `kanban_tasks.rank COLLATE "C"`
+
+func moveHandlers(route fiber.Router, db *gorm.DB, kanbanServer *redis.KanbanServer) {
+	// Places a task right before or after another task, optionally in another
+	// column. Without before_id/after_id the task goes to the end of the column.
+	route.Patch("/:taskID/move", taskAccess(db, models.SpacePermissionEditTasks), func(c *fiber.Ctx) error {
+		userId, ok := c.Locals("userId").(uint)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to get user details. Please login again",
+			})
+		}
+
+		task, err := getTask(db, c.Params("taskID"))
+		if err != nil {
+			return taskLookupError(c, err)
+		}
+		before := task
+
+		type MoveTaskRequest struct {
+			Status   *string `json:"status"`
+			BeforeID *uint   `json:"before_id"`
+			AfterID  *uint   `json:"after_id"`
+		}
+		req := new(MoveTaskRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid request body",
+			})
+		}
+
+		if req.BeforeID != nil && req.AfterID != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Provide either before_id or after_id, not both",
+			})
+		}
+
+		var reranked map[uint]string
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := lockBoard(tx, task.SpaceID); err != nil {
+				return err
+			}
+
+			anchorId := req.BeforeID
+			if anchorId == nil {
+				anchorId = req.AfterID
+			}
+
+			var anchor *models.KanbanTasks
+			if anchorId != nil {
+				if *anchorId == task.ID {
+					return fiber.NewError(fiber.StatusBadRequest, "A task cannot be moved relative to itself")
+				}
+
+				anchor = new(models.KanbanTasks)
+				if err := tx.Where("id = ? AND space_id = ?", *anchorId, task.SpaceID).First(anchor).Error; err != nil {
+					return fiber.NewError(fiber.StatusNotFound, "Anchor task not found")
+				}
+			}
+
+			status := task.Status
+			if req.Status != nil {
+				status = models.TaskStatus(*req.Status)
+			} else if anchor != nil {
+				status = anchor.Status
+			}
+
+			if anchor != nil && anchor.Status != status {
+				return fiber.NewError(fiber.StatusBadRequest, "The anchor task is not in the target column")
+			}
+
+			if status != task.Status {
+				task.Status = status
+				if _, err := resolveTaskColumn(tx, &task); err != nil {
+					return err
+				}
+			}
+
+			if needsRebalance, err := hasRankCollisions(tx, task.SpaceID, status, task.ID); err != nil {
+				return err
+			} else if needsRebalance {
+				if reranked, err = rebalanceColumn(tx, task.SpaceID, status, task.ID); err != nil {
+					return err
+				}
+			}
+
+			rank, err := rankForMove(tx, task, anchor, req.BeforeID != nil)
+			if err != nil {
+				return err
+			}
+
+			if len(rank) > maxRankLength {
+				if reranked, err = rebalanceColumn(tx, task.SpaceID, status, task.ID); err != nil {
+					return err
+				}
+				if rank, err = rankForMove(tx, task, anchor, req.BeforeID != nil); err != nil {
+					return err
+				}
+			}
+
+			task.Rank = rank
+			if err := tx.Model(&task).Select("status", "rank").Updates(&task).Error; err != nil {
+				return err
+			}
+
+			return recordActivity(tx, taskChanges(before, task, userId)...)
+		})
+		if err != nil {
+			return boardError(c, err, "Failed to move task")
+		}
+
+		publishReranked(kanbanServer, task.SpaceID, task.Status, reranked)
+
+		if err := withTaskRelations(db).First(&task, task.ID).Error; err != nil {
+			log.Warnf("Error loading Task: %v", err.Error())
+		}
+
+		if err := kanbanServer.Publish(task.SpaceID, "task_updated", task); err != nil {
+			log.Errorf("Failed to publish kanban event: %v", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Task moved successfully",
+			"data":    task,
+		})
+	})
+}
+
+// rankForMove computes the new rank of task next to anchor, or at the end of
+// the column when there is no anchor. The anchor's rank is re-read because a
+// rebalance may have changed it.
+func rankForMove(tx *gorm.DB, task models.KanbanTasks, anchor *models.KanbanTasks, before bool) (string, error) {
+	if anchor == nil {
+		return rankAtColumnEnd(tx, task.SpaceID, task.Status, task.ID)
+	}
+
+	if err := tx.Select("rank").First(anchor, anchor.ID).Error; err != nil {
+		return "", err
+	}
+
+	column := tx.Model(&models.KanbanTasks{}).
+		Where("space_id = ? AND status = ? AND id <> ?", task.SpaceID, task.Status, task.ID)
+
+	var neighbours []string
+	if before {
+		if err := column.Where(rankOrder+" < ?", anchor.Rank).
+			Order(rankOrder+" DESC").Limit(1).Pluck("rank", &neighbours).Error; err != nil {
+			return "", err
+		}
+
+		prev := ""
+		if len(neighbours) > 0 {
+			prev = neighbours[0]
+		}
+		return rankBetween(prev, anchor.Rank), nil
+	}
+
+	if err := column.Where(rankOrder+" > ?", anchor.Rank).
+		Order(rankOrder).Limit(1).Pluck("rank", &neighbours).Error; err != nil {
+		return "", err
+	}
+
+	next := ""
+	if len(neighbours) > 0 {
+		next = neighbours[0]
+	}
+	return rankBetween(anchor.Rank, next), nil
+}
+
+// rankAtColumnEnd returns a rank placing a task after every other task of the
+// column, ignoring the task with excludeId.
+func rankAtColumnEnd(tx *gorm.DB, spaceId uint, status models.TaskStatus, excludeId uint) (string, error) {
+	var last []string
+	if err := tx.Model(&models.KanbanTasks{}).
+		Where("space_id = ? AND status = ? AND id <> ?", spaceId, status, excludeId).
+		Order(rankOrder+" DESC").Limit(1).Pluck("rank", &last).Error; err != nil {
+		return "", err
+	}
+
+	prev := ""
+	if len(last) > 0 {
+		prev = last[0]
+	}
+
+	return rankBetween(prev, ""), nil
+}
+
+// appendToColumn ranks a task at the end of its column, rebalancing the column
+// first when appending has made the ranks too long.
+func appendToColumn(tx *gorm.DB, task *models.KanbanTasks) (map[uint]string, error) {
+	rank, err := rankAtColumnEnd(tx, task.SpaceID, task.Status, task.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var reranked map[uint]string
+	if len(rank) > maxRankLength {
+		if reranked, err = rebalanceColumn(tx, task.SpaceID, task.Status, task.ID); err != nil {
+			return nil, err
+		}
+		if rank, err = rankAtColumnEnd(tx, task.SpaceID, task.Status, task.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	task.Rank = rank
+	return reranked, nil
+}
+
+// hasRankCollisions reports whether tasks of a column share a rank or have
+// none yet, which happens for tasks created before ranks existed.
+func hasRankCollisions(tx *gorm.DB, spaceId uint, status models.TaskStatus, excludeId uint) (bool, error) {
+	var stats struct {
+		Total         int64
+		DistinctRanks int64
+		Empty         int64
+	}
+
+	err := tx.Model(&models.KanbanTasks{}).
+		Select("COUNT(*) AS total, COUNT(DISTINCT rank) AS distinct_ranks, COUNT(*) FILTER (WHERE rank = '') AS empty").
+		Where("space_id = ? AND status = ? AND id <> ?", spaceId, status, excludeId).
+		Scan(&stats).Error
+	if err != nil {
+		return false, err
+	}
+
+	return stats.Total != stats.DistinctRanks || stats.Empty > 0, nil
+}
+
+// rebalanceColumn spreads the ranks of a column evenly, keeping the current
+// order, and returns the new rank of every task it touched.
+func rebalanceColumn(tx *gorm.DB, spaceId uint, status models.TaskStatus, excludeId uint) (map[uint]string, error) {
+	var tasks []models.KanbanTasks
+	if err := tx.Select("id", "rank").
+		Where("space_id = ? AND status = ? AND id <> ?", spaceId, status, excludeId).
+		Order(rankOrder).Order("id").
+		Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+
+	ranks := evenRanks(len(tasks))
+	reranked := make(map[uint]string, len(tasks))
+
+	for i, task := range tasks {
+		if err := tx.Model(&task).UpdateColumn("rank", ranks[i]).Error; err != nil {
+			return nil, err
+		}
+		reranked[task.ID] = ranks[i]
+	}
+
+	return reranked, nil
+}
+
+func publishReranked(kanbanServer *redis.KanbanServer, spaceId uint, status models.TaskStatus, reranked map[uint]string) {
+	if len(reranked) == 0 {
+		return
+	}
+
+	if err := kanbanServer.Publish(spaceId, "column_reranked", fiber.Map{
+		"status": status,
+		"ranks":  reranked,
+	}); err != nil {
+		log.Errorf("Failed to publish kanban event: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package kanban
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/bhav-07/haven/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultTaskLimit = 100
+	maxTaskLimit     = 200
+)
+
+// Tasks whose status matches no column sort after every real column
+const missingColumnPosition = math.MaxInt32
+
+// taskSort describes one of the orderings accepted by GET /kanban?sort=.
+// keys are SQL expressions ending with the task id so the order is total,
+// values extracts the same keys from a loaded task to build the next cursor.
+type taskSort struct {
+	keys   []string
+	values func(task models.KanbanTasks, positions map[models.TaskStatus]int) []interface{}
+}
+
+var taskSorts = map[string]taskSort{
+	"board": {
+		keys: []string{"COALESCE(kanban_columns.position, 2147483647)", rankOrder, "kanban_tasks.id"},
+		values: func(task models.KanbanTasks, positions map[models.TaskStatus]int) []interface{} {
+			position, ok := positions[task.Status]
+			if !ok {
+				position = missingColumnPosition
+			}
+			return []interface{}{position, task.Rank, task.ID}
+		},
+	},
+	"due_date": {
+		// Tasks without a due date come last
+		keys: []string{"COALESCE(NULLIF(kanban_tasks.due_date, ''), '9999-12-31')", "kanban_tasks.id"},
+		values: func(task models.KanbanTasks, _ map[models.TaskStatus]int) []interface{} {
+			dueDate := task.DueDate
+			if dueDate == "" {
+				dueDate = "9999-12-31"
+			}
+			return []interface{}{dueDate, task.ID}
+		},
+	},
+	"priority": {
+		keys: []string{"CASE kanban_tasks.priority WHEN 'HIGH' THEN 0 WHEN 'MEDIUM' THEN 1 ELSE 2 END", "kanban_tasks.id"},
+		values: func(task models.KanbanTasks, _ map[models.TaskStatus]int) []interface{} {
+			return []interface{}{priorityWeight(task.Priority), task.ID}
+		},
+	},
+	"created": {
+		keys: []string{"kanban_tasks.created_at", "kanban_tasks.id"},
+		values: func(task models.KanbanTasks, _ map[models.TaskStatus]int) []interface{} {
+			return []interface{}{task.CreatedAt.UTC().Format(time.RFC3339Nano), task.ID}
+		},
+	},
+}
+
+func priorityWeight(priority models.TaskPriority) int {
+	switch priority {
+	case models.TaskPriorityHigh:
+		return 0
+	case models.TaskPriorityMedium:
+		return 1
+	}
+	return 2
+}
+
+// orderBy applies the sort to the query, every key in the same direction.
+func (s taskSort) orderBy(query *gorm.DB, descending bool) *gorm.DB {
+	for _, key := range s.keys {
+		if descending {
+			key += " DESC"
+		}
+		query = query.Order(key)
+	}
+	return query
+}
+
+// after restricts the query to rows that come after the cursor, using a row
+// value comparison so Postgres can walk the keys in one go.
+func (s taskSort) after(query *gorm.DB, cursor []interface{}, descending bool) *gorm.DB {
+	operator := ">"
+	if descending {
+		operator = "<"
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(s.keys)), ", ")
+	condition := fmt.Sprintf("(%s) %s (%s)", strings.Join(s.keys, ", "), operator, placeholders)
+	return query.Where(condition, cursor...)
+}
+
+func encodeTaskCursor(values []interface{}) string {
+	data, _ := json.Marshal(values)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeTaskCursor(cursor string, sort taskSort) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+
+	if len(values) != len(sort.keys) {
+		return nil, fmt.Errorf("cursor has %d values, expected %d", len(values), len(sort.keys))
+	}
+
+	// JSON numbers decode as float64, but every numeric key is an integer column
+	for i, value := range values {
+		if number, ok := value.(float64); ok {
+			values[i] = int64(number)
+		}
+	}
+
+	return values, nil
+}
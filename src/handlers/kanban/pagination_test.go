@@ -0,0 +1,82 @@
+package kanban
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bhav-07/haven/models"
+	"gorm.io/gorm"
+)
+
+func TestTaskCursorRoundTrip(t *testing.T) {
+	task := models.KanbanTasks{
+		Model:    gorm.Model{ID: 42, CreatedAt: time.Date(2026, 10, 18, 9, 30, 0, 123456789, time.UTC)},
+		Status:   models.TaskStatus("in_progress"),
+		Priority: models.TaskPriorityMedium,
+		DueDate:  "2026-11-02",
+		Rank:     "i5",
+	}
+	positions := map[models.TaskStatus]int{"in_progress": 2}
+
+	tests := []struct {
+		name string
+		sort string
+		task models.KanbanTasks
+		want []interface{}
+	}{
+		{"board", "board", task, []interface{}{int64(2), "i5", int64(42)}},
+		{"board without a column", "board", withStatus(task, "archived"), []interface{}{int64(missingColumnPosition), "i5", int64(42)}},
+		{"due date", "due_date", task, []interface{}{"2026-11-02", int64(42)}},
+		{"no due date", "due_date", withDueDate(task, ""), []interface{}{"9999-12-31", int64(42)}},
+		{"priority", "priority", task, []interface{}{int64(1), int64(42)}},
+		{"created", "created", task, []interface{}{"2026-10-18T09:30:00.123456789Z", int64(42)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sort := taskSorts[tt.sort]
+			cursor := encodeTaskCursor(sort.values(tt.task, positions))
+
+			got, err := decodeTaskCursor(cursor, sort)
+			if err != nil {
+				t.Fatalf("decodeTaskCursor(%q) failed: %v", cursor, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeTaskCursor(%q) = %#v, want %#v", cursor, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeTaskCursorRejectsInvalidCursors(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "%%%"},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("tasks"))},
+		{"not an array", base64.RawURLEncoding.EncodeToString([]byte(`{"id":1}`))},
+		{"too few values", base64.RawURLEncoding.EncodeToString([]byte(`[1]`))},
+		{"too many values", base64.RawURLEncoding.EncodeToString([]byte(`[1,"a",2,3]`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if values, err := decodeTaskCursor(tt.cursor, taskSorts["board"]); err == nil {
+				t.Errorf("decodeTaskCursor(%q) = %#v, want an error", tt.cursor, values)
+			}
+		})
+	}
+}
+
+func withStatus(task models.KanbanTasks, status models.TaskStatus) models.KanbanTasks {
+	task.Status = status
+	return task
+}
+
+func withDueDate(task models.KanbanTasks, dueDate string) models.KanbanTasks {
+	task.DueDate = dueDate
+	return task
+}
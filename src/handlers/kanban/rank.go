@@ -0,0 +1,87 @@
+package kanban
+
+import "strings"
+
+// Ranks are base-36 strings compared byte by byte. A new rank can always be
+// generated between two existing ones, so moving a card only rewrites that card.
+// Ranks never end in '0', otherwise there would be no room left below them.
+const rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// rankBetween returns a rank that sorts strictly between prev and next.
+// An empty prev means the start of the column, an empty next its end.
+func rankBetween(prev, next string) string {
+	if next != "" {
+		// Skip the shared prefix, reading missing digits of prev as zeros
+		n := 0
+		for n < len(next) && rankDigitAt(prev, n) == rankDigitIndex(next[n]) {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(prev) {
+				rest = prev[n:]
+			}
+			return next[:n] + rankBetween(rest, next[n:])
+		}
+	}
+
+	low := rankDigitAt(prev, 0)
+	high := len(rankDigits)
+	if next != "" {
+		high = rankDigitIndex(next[0])
+	}
+
+	if high-low > 1 {
+		return string(rankDigits[(low+high)/2])
+	}
+
+	// The first digits are adjacent
+	if len(next) > 1 {
+		return next[:1]
+	}
+
+	rest := ""
+	if len(prev) > 1 {
+		rest = prev[1:]
+	}
+	return string(rankDigits[low]) + rankBetween(rest, "")
+}
+
+// evenRanks returns count evenly spaced, ascending ranks of equal length,
+// used to rebalance a column whose ranks grew too long or collided.
+func evenRanks(count int) []string {
+	width := 1
+	for capacity := len(rankDigits); capacity <= count; capacity *= len(rankDigits) {
+		width++
+	}
+
+	space := 1
+	for i := 0; i < width; i++ {
+		space *= len(rankDigits)
+	}
+	step := space / (count + 1)
+
+	ranks := make([]string, count)
+	for i := range ranks {
+		value := (i + 1) * step
+		digits := make([]byte, width)
+		for d := width - 1; d >= 0; d-- {
+			digits[d] = rankDigits[value%len(rankDigits)]
+			value /= len(rankDigits)
+		}
+		ranks[i] = strings.TrimRight(string(digits), "0")
+	}
+
+	return ranks
+}
+
+func rankDigitAt(rank string, i int) int {
+	if i >= len(rank) {
+		return 0
+	}
+	return rankDigitIndex(rank[i])
+}
+
+func rankDigitIndex(digit byte) int {
+	return strings.IndexByte(rankDigits, digit)
+}
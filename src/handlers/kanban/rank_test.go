@@ -0,0 +1,115 @@
+package kanban
+
+import (
+	"strings"
+	"testing"
+)
+
+func assertRankBetween(t *testing.T, prev, next, rank string) {
+	t.Helper()
+	if rank == "" {
+		t.Fatalf("rankBetween(%q, %q) returned an empty rank", prev, next)
+	}
+	if strings.HasSuffix(rank, "0") {
+		t.Errorf("rankBetween(%q, %q) = %q ends in '0'", prev, next, rank)
+	}
+	if prev != "" && rank <= prev {
+		t.Errorf("rankBetween(%q, %q) = %q does not sort after prev", prev, next, rank)
+	}
+	if next != "" && rank >= next {
+		t.Errorf("rankBetween(%q, %q) = %q does not sort before next", prev, next, rank)
+	}
+}
+
+func TestRankBetween(t *testing.T) {
+	tests := []struct {
+		name string
+		prev string
+		next string
+	}{
+		{"empty column", "", ""},
+		{"start of column", "", "i"},
+		{"end of column", "i", ""},
+		{"wide gap", "a", "z"},
+		{"adjacent digits", "a", "b"},
+		{"next extends prev", "a", "a1"},
+		{"shared prefix", "ab", "ac"},
+		{"before the lowest rank", "", "1"},
+		{"after the highest digit", "z", ""},
+		{"after a long rank", "zzzz", ""},
+		{"prev longer than next", "az", "b"},
+		{"deep shared prefix", "i1", "i2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertRankBetween(t, tt.prev, tt.next, rankBetween(tt.prev, tt.next))
+		})
+	}
+}
+
+func TestRankBetweenRepeatedInserts(t *testing.T) {
+	tests := []struct {
+		name string
+		// bounds places the next card, given the first card and the last one inserted
+		bounds func(first, last string) (string, string)
+	}{
+		{"top of column", func(_, last string) (string, string) { return "", last }},
+		{"bottom of column", func(_, last string) (string, string) { return last, "" }},
+		{"right after the first card", func(first, last string) (string, string) {
+			if last == first {
+				return first, ""
+			}
+			return first, last
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := rankBetween("", "")
+			last := first
+			for i := 0; i < 200; i++ {
+				prev, next := tt.bounds(first, last)
+				rank := rankBetween(prev, next)
+				assertRankBetween(t, prev, next, rank)
+				last = rank
+			}
+		})
+	}
+}
+
+func TestEvenRanks(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+	}{
+		{"single card", 1},
+		{"fits one digit", 34},
+		{"needs two digits", 36},
+		{"large column", 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranks := evenRanks(tt.count)
+			if len(ranks) != tt.count {
+				t.Fatalf("evenRanks(%d) returned %d ranks", tt.count, len(ranks))
+			}
+			for i, rank := range ranks {
+				if rank == "" || strings.HasSuffix(rank, "0") {
+					t.Errorf("rank %d = %q is empty or ends in '0'", i, rank)
+				}
+				if i > 0 && rank <= ranks[i-1] {
+					t.Errorf("rank %d = %q does not sort after %q", i, rank, ranks[i-1])
+				}
+			}
+
+			// There is room to insert before, between and after the rebalanced ranks
+			assertRankBetween(t, "", ranks[0], rankBetween("", ranks[0]))
+			assertRankBetween(t, ranks[len(ranks)-1], "", rankBetween(ranks[len(ranks)-1], ""))
+			if len(ranks) > 1 {
+				assertRankBetween(t, ranks[0], ranks[1], rankBetween(ranks[0], ranks[1]))
+			}
+		})
+	}
+}
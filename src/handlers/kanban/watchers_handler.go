@@ -0,0 +1,174 @@
+package kanban
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/bhav-07/haven/middleware"
+	"github.com/bhav-07/haven/models"
+	"github.com/bhav-07/haven/redis"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+func watcherHandlers(route fiber.Router, db *gorm.DB, kanbanServer *redis.KanbanServer) {
+	route.Post("/:taskID/watchers", taskAccess(db, models.SpacePermissionEditTasks), func(c *fiber.Ctx) error {
+		userId, ok := c.Locals("userId").(uint)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to get user details. Please login again",
+			})
+		}
+
+		task, err := getTask(db, c.Params("taskID"))
+		if err != nil {
+			return taskLookupError(c, err)
+		}
+
+		// Without a user_id the caller starts watching the task themselves
+		type AddWatcherRequest struct {
+			UserID *uint `json:"user_id"`
+		}
+		req := new(AddWatcherRequest)
+
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"status": "error",
+					"error":  "Invalid request body",
+				})
+			}
+		}
+
+		watcherId := userId
+		if req.UserID != nil {
+			watcherId = *req.UserID
+		}
+
+		if err := validateSpaceMember(db, task.SpaceID, watcherId); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  err.Error(),
+			})
+		}
+
+		if err := addWatchers(db, task.ID, watcherId); err != nil {
+			log.Warnf("Error adding watcher: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to add watcher",
+			})
+		}
+
+		return publishWatchersChanged(c, db, kanbanServer, task.ID, "Watcher added successfully")
+	})
+
+	route.Delete("/:taskID/watchers/:userID", taskAccess(db, models.SpacePermissionEditTasks), func(c *fiber.Ctx) error {
+		userId, ok := c.Locals("userId").(uint)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to get user details. Please login again",
+			})
+		}
+
+		task, err := getTask(db, c.Params("taskID"))
+		if err != nil {
+			return taskLookupError(c, err)
+		}
+
+		watcherId, err := resolveUserFilter(c.Params("userID"), userId)
+		if err != nil {
+			log.Warn("Unable to convert string to uint")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to convert string to uint",
+			})
+		}
+
+		// Members may only stop their own watches
+		if watcherId != userId && !middleware.CurrentSpaceRole(c).Can(models.SpacePermissionManageBoard) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status": "error",
+				"error":  "You can only remove your own watch",
+			})
+		}
+
+		result := db.Table("kanban_task_watchers").
+			Where("kanban_tasks_id = ? AND user_id = ?", task.ID, watcherId).
+			Delete(map[string]interface{}{})
+		if result.Error != nil {
+			log.Warnf("Error removing watcher: %v", result.Error.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to remove watcher",
+			})
+		}
+
+		if result.RowsAffected == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status": "error",
+				"error":  "User is not watching this task",
+			})
+		}
+
+		return publishWatchersChanged(c, db, kanbanServer, task.ID, "Watcher removed successfully")
+	})
+}
+
+func publishWatchersChanged(c *fiber.Ctx, db *gorm.DB, kanbanServer *redis.KanbanServer, taskId uint, message string) error {
+	var task models.KanbanTasks
+	if err := withTaskRelations(db).First(&task, taskId).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Failed to load task",
+		})
+	}
+
+	if err := kanbanServer.Publish(task.SpaceID, "task_updated", task); err != nil {
+		log.Errorf("Failed to publish kanban event: %v", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": message,
+		"data":    task,
+	})
+}
+
+func getTask(db *gorm.DB, taskIdString string) (models.KanbanTasks, error) {
+	var task models.KanbanTasks
+
+	taskId, err := strconv.ParseUint(taskIdString, 10, 64)
+	if err != nil {
+		return task, err
+	}
+
+	err = db.First(&task, taskId).Error
+	return task, err
+}
+
+func taskLookupError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Task not found",
+		})
+	}
+
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		log.Warn("Unable to convert string to uint")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Unable to convert string to uint",
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"status": "error",
+		"error":  "Failed to load task",
+	})
+}
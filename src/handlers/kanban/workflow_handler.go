@@ -0,0 +1,503 @@
+package kanban
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bhav-07/haven/models"
+	"github.com/bhav-07/haven/redis"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+// Namespace for the Postgres advisory locks that serialize board writes per space
+const boardLockNamespace = 4201
+
+var defaultColumns = []models.KanbanColumn{
+	{Name: models.TaskStatusTodo, Color: "#a3a3a3"},
+	{Name: models.TaskStatusInProgress, Color: "#3b82f6"},
+	{Name: models.TaskStatusInReview, Color: "#f59e0b"},
+	{Name: models.TaskStatusDone, Color: "#22c55e", IsDone: true},
+}
+
+var errUnknownStatus = errors.New("Status does not match any column in this space's workflow")
+
+type wipLimitError struct {
+	column models.KanbanColumn
+}
+
+func (e *wipLimitError) Error() string {
+	return fmt.Sprintf("Column %s has reached its WIP limit of %d tasks", e.column.Name, e.column.WIPLimit)
+}
+
+func workflowHandlers(route fiber.Router, db *gorm.DB, kanbanServer *redis.KanbanServer) {
+	route.Get("/workflow", boardAccess(db, models.SpacePermissionView), func(c *fiber.Ctx) error {
+		spaceId, err := strconv.ParseUint(c.Query("spaceID"), 10, 64)
+		if err != nil {
+			log.Warn("Unable to convert string to uint")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to convert string to uint",
+			})
+		}
+
+		var columns []models.KanbanColumn
+		err = db.Transaction(func(tx *gorm.DB) error {
+			columns, err = ensureWorkflow(tx, uint(spaceId))
+			return err
+		})
+		if err != nil {
+			log.Warnf("Error loading workflow: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to load workflow",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"status": "success",
+			"data":   columns,
+		})
+	})
+
+	route.Post("/workflow", boardAccess(db, models.SpacePermissionManageBoard), func(c *fiber.Ctx) error {
+		spaceId, err := strconv.ParseUint(c.Query("spaceID"), 10, 64)
+		if err != nil {
+			log.Warn("Unable to convert string to uint")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to convert string to uint",
+			})
+		}
+
+		column := new(models.KanbanColumn)
+		if err := c.BodyParser(column); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid request body",
+			})
+		}
+
+		column.Model = gorm.Model{}
+		column.SpaceID = uint(spaceId)
+		if err := validateColumn(column); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  err.Error(),
+			})
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			columns, err := ensureWorkflow(tx, column.SpaceID)
+			if err != nil {
+				return err
+			}
+
+			for _, existing := range columns {
+				if existing.Name == column.Name {
+					return fiber.NewError(fiber.StatusConflict, "A column with this name already exists")
+				}
+			}
+
+			column.Position = len(columns)
+			return tx.Create(column).Error
+		})
+		if err != nil {
+			return boardError(c, err, "Failed to create column")
+		}
+
+		publishWorkflow(db, kanbanServer, column.SpaceID)
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Column created successfully",
+			"data":    column,
+		})
+	})
+
+	// Reorders the whole workflow, column_ids must list every column of the space
+	route.Put("/workflow/order", boardAccess(db, models.SpacePermissionManageBoard), func(c *fiber.Ctx) error {
+		spaceId, err := strconv.ParseUint(c.Query("spaceID"), 10, 64)
+		if err != nil {
+			log.Warn("Unable to convert string to uint")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to convert string to uint",
+			})
+		}
+
+		type ReorderColumnsRequest struct {
+			ColumnIDs []uint `json:"column_ids"`
+		}
+		req := new(ReorderColumnsRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid request body",
+			})
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			columns, err := ensureWorkflow(tx, uint(spaceId))
+			if err != nil {
+				return err
+			}
+
+			if len(req.ColumnIDs) != len(columns) {
+				return fiber.NewError(fiber.StatusBadRequest, "column_ids must contain every column of the workflow")
+			}
+
+			existing := make(map[uint]bool, len(columns))
+			for _, column := range columns {
+				existing[column.ID] = true
+			}
+
+			for position, columnId := range req.ColumnIDs {
+				if !existing[columnId] {
+					return fiber.NewError(fiber.StatusBadRequest, "column_ids must contain every column of the workflow")
+				}
+				delete(existing, columnId)
+
+				if err := tx.Model(&models.KanbanColumn{}).Where("id = ?", columnId).Update("position", position).Error; err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return boardError(c, err, "Failed to reorder columns")
+		}
+
+		columns := publishWorkflow(db, kanbanServer, uint(spaceId))
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Columns reordered successfully",
+			"data":    columns,
+		})
+	})
+
+	route.Patch("/workflow/:columnID", columnAccess(db, models.SpacePermissionManageBoard), func(c *fiber.Ctx) error {
+		column, err := getColumn(db, c.Params("columnID"))
+		if err != nil {
+			return columnLookupError(c, err)
+		}
+
+		type UpdateColumnRequest struct {
+			Name     *string `json:"name"`
+			Color    *string `json:"color"`
+			WIPLimit *int    `json:"wip_limit"`
+			IsDone   *bool   `json:"is_done"`
+		}
+		req := new(UpdateColumnRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid request body",
+			})
+		}
+
+		oldName := column.Name
+		var fields []string
+
+		if req.Name != nil {
+			column.Name = models.TaskStatus(*req.Name)
+			fields = append(fields, "name")
+		}
+		if req.Color != nil {
+			column.Color = *req.Color
+			fields = append(fields, "color")
+		}
+		if req.WIPLimit != nil {
+			column.WIPLimit = *req.WIPLimit
+			fields = append(fields, "wip_limit")
+		}
+		if req.IsDone != nil {
+			column.IsDone = *req.IsDone
+			fields = append(fields, "is_done")
+		}
+
+		if len(fields) == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "No fields to update",
+			})
+		}
+
+		if err := validateColumn(&column); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  err.Error(),
+			})
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := lockBoard(tx, column.SpaceID); err != nil {
+				return err
+			}
+
+			if column.Name != oldName {
+				var count int64
+				if err := tx.Model(&models.KanbanColumn{}).
+					Where("space_id = ? AND name = ? AND id <> ?", column.SpaceID, column.Name, column.ID).
+					Count(&count).Error; err != nil {
+					return err
+				}
+				if count > 0 {
+					return fiber.NewError(fiber.StatusConflict, "A column with this name already exists")
+				}
+
+				// Tasks reference their column by name, so they follow the rename
+				if err := tx.Model(&models.KanbanTasks{}).
+					Where("space_id = ? AND status = ?", column.SpaceID, oldName).
+					Update("status", column.Name).Error; err != nil {
+					return err
+				}
+			}
+
+			return tx.Model(&column).Select(fields).Updates(&column).Error
+		})
+		if err != nil {
+			return boardError(c, err, "Failed to update column")
+		}
+
+		publishWorkflow(db, kanbanServer, column.SpaceID)
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Column updated successfully",
+			"data":    column,
+		})
+	})
+
+	route.Delete("/workflow/:columnID", columnAccess(db, models.SpacePermissionManageBoard), func(c *fiber.Ctx) error {
+		column, err := getColumn(db, c.Params("columnID"))
+		if err != nil {
+			return columnLookupError(c, err)
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := lockBoard(tx, column.SpaceID); err != nil {
+				return err
+			}
+
+			var taskCount int64
+			if err := tx.Model(&models.KanbanTasks{}).
+				Where("space_id = ? AND status = ?", column.SpaceID, column.Name).
+				Count(&taskCount).Error; err != nil {
+				return err
+			}
+			if taskCount > 0 {
+				return fiber.NewError(fiber.StatusConflict, "Move the tasks out of this column before deleting it")
+			}
+
+			var columnCount int64
+			if err := tx.Model(&models.KanbanColumn{}).
+				Where("space_id = ?", column.SpaceID).
+				Count(&columnCount).Error; err != nil {
+				return err
+			}
+			if columnCount <= 1 {
+				return fiber.NewError(fiber.StatusConflict, "A workflow needs at least one column")
+			}
+
+			if err := tx.Delete(&column).Error; err != nil {
+				return err
+			}
+
+			return tx.Model(&models.KanbanColumn{}).
+				Where("space_id = ? AND position > ?", column.SpaceID, column.Position).
+				Update("position", gorm.Expr("position - 1")).Error
+		})
+		if err != nil {
+			return boardError(c, err, "Failed to delete column")
+		}
+
+		publishWorkflow(db, kanbanServer, column.SpaceID)
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Column deleted successfully",
+		})
+	})
+}
+
+// ensureWorkflow returns the ordered columns of a space, seeding the default
+// workflow the first time a space's board is used. It must run in a transaction.
+func ensureWorkflow(tx *gorm.DB, spaceId uint) ([]models.KanbanColumn, error) {
+	if err := lockBoard(tx, spaceId); err != nil {
+		return nil, err
+	}
+
+	var columns []models.KanbanColumn
+	if err := tx.Where("space_id = ?", spaceId).Order("position").Find(&columns).Error; err != nil {
+		return nil, err
+	}
+
+	if len(columns) > 0 {
+		return columns, nil
+	}
+
+	for position, column := range defaultColumns {
+		column.SpaceID = spaceId
+		column.Position = position
+		columns = append(columns, column)
+	}
+
+	if err := tx.Create(&columns).Error; err != nil {
+		return nil, err
+	}
+
+	return columns, nil
+}
+
+// lockBoard serializes writes to a space's board until the transaction ends,
+// so WIP limits hold when several replicas move cards at once.
+func lockBoard(tx *gorm.DB, spaceId uint) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", boardLockNamespace, int32(spaceId)).Error
+}
+
+// resolveTaskColumn checks the task's status against the space workflow and
+// the target column's WIP limit. An empty status places the task in the first column.
+func resolveTaskColumn(tx *gorm.DB, task *models.KanbanTasks) (*models.KanbanColumn, error) {
+	columns, err := ensureWorkflow(tx, task.SpaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.Status == "" {
+		task.Status = columns[0].Name
+	}
+
+	var column *models.KanbanColumn
+	for i := range columns {
+		if columns[i].Name == task.Status {
+			column = &columns[i]
+			break
+		}
+	}
+
+	if column == nil {
+		return nil, errUnknownStatus
+	}
+
+	if column.WIPLimit > 0 {
+		var count int64
+		if err := tx.Model(&models.KanbanTasks{}).
+			Where("space_id = ? AND status = ? AND id <> ?", task.SpaceID, column.Name, task.ID).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+
+		if count >= int64(column.WIPLimit) {
+			return nil, &wipLimitError{column: *column}
+		}
+	}
+
+	return column, nil
+}
+
+func validateColumn(column *models.KanbanColumn) error {
+	column.Name = models.TaskStatus(strings.TrimSpace(string(column.Name)))
+	if column.Name == "" {
+		return errors.New("Column name cannot be empty")
+	}
+
+	if column.WIPLimit < 0 {
+		return errors.New("WIP limit cannot be negative, use 0 for no limit")
+	}
+
+	if column.Color == "" {
+		column.Color = "#a3a3a3"
+	}
+
+	return nil
+}
+
+func publishWorkflow(db *gorm.DB, kanbanServer *redis.KanbanServer, spaceId uint) []models.KanbanColumn {
+	var columns []models.KanbanColumn
+	if err := db.Where("space_id = ?", spaceId).Order("position").Find(&columns).Error; err != nil {
+		log.Warnf("Error loading workflow: %v", err.Error())
+		return columns
+	}
+
+	if err := kanbanServer.Publish(spaceId, "workflow_updated", columns); err != nil {
+		log.Errorf("Failed to publish kanban event: %v", err)
+	}
+
+	return columns
+}
+
+func getColumn(db *gorm.DB, columnIdString string) (models.KanbanColumn, error) {
+	var column models.KanbanColumn
+
+	columnId, err := strconv.ParseUint(columnIdString, 10, 64)
+	if err != nil {
+		return column, err
+	}
+
+	err = db.First(&column, columnId).Error
+	return column, err
+}
+
+func columnLookupError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Column not found",
+		})
+	}
+
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		log.Warn("Unable to convert string to uint")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Unable to convert string to uint",
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"status": "error",
+		"error":  "Failed to load column",
+	})
+}
+
+// boardError maps errors returned while reading or writing the board to
+// responses, falling back to a 500 with the given message.
+func boardError(c *fiber.Ctx, err error, fallback string) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return c.Status(fiberErr.Code).JSON(fiber.Map{
+			"status": "error",
+			"error":  fiberErr.Message,
+		})
+	}
+
+	var wipErr *wipLimitError
+	if errors.As(err, &wipErr) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":    "error",
+			"error":     wipErr.Error(),
+			"column":    wipErr.column.Name,
+			"wip_limit": wipErr.column.WIPLimit,
+		})
+	}
+
+	if errors.Is(err, errUnknownStatus) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"error":  err.Error(),
+		})
+	}
+
+	log.Warnf("%s: %v", fallback, err.Error())
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"status": "error",
+		"error":  fallback,
+	})
+}
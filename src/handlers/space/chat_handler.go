@@ -0,0 +1,50 @@
+package space
+
+import (
+	"github.com/bhav-07/haven/middleware"
+	"github.com/bhav-07/haven/redis"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultChatLimit = 50
+	maxChatLimit     = 100
+)
+
+func chatHandlers(route fiber.Router, db *gorm.DB) {
+	// Pages back through the chat of a space. Messages come oldest first,
+	// pass the id of the first one as before to get the page preceding it.
+	route.Get("/space/:id/chat", middleware.SpaceMember(db, middleware.SpaceFromParam("id")), func(c *fiber.Ctx) error {
+		space, _ := middleware.CurrentSpace(c)
+
+		before := c.QueryInt("before", 0)
+		if before < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid before message id",
+			})
+		}
+
+		limit := c.QueryInt("limit", defaultChatLimit)
+		if limit <= 0 || limit > maxChatLimit {
+			limit = defaultChatLimit
+		}
+
+		messages, err := redis.ChatHistory(db, space.ID, uint(before), limit)
+		if err != nil {
+			log.Warnf("Error fetching chat messages: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to fetch chat messages",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"status":   "success",
+			"data":     messages,
+			"has_more": len(messages) == limit,
+		})
+	})
+}
@@ -0,0 +1,313 @@
+package space
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/bhav-07/haven/models"
+	"github.com/bhav-07/haven/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+func cloneHandlers(route fiber.Router, db *gorm.DB) {
+	route.Get("/spaces/templates", func(c *fiber.Ctx) error {
+		var templates []models.Space
+		if err := db.Where("is_template AND archived_at IS NULL").Order("name").Find(&templates).Error; err != nil {
+			log.Warnf("Error fetching templates: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to fetch templates",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"status": "success",
+			"data":   templates,
+		})
+	})
+
+	// Copies a space into a new one owned by the caller. Members can clone their
+	// spaces, anyone can clone a template. The whiteboard and zones are always copied,
+	// the workflow and labels unless include_workflow is false, tasks only with include_tasks.
+	route.Post("/space/:id/clone", func(c *fiber.Ctx) error {
+		userId, ok := c.Locals("userId").(uint)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to get user details. Please login again",
+			})
+		}
+
+		spaceId, err := strconv.ParseUint(c.Params("id"), 10, 64)
+		if err != nil {
+			log.Warn("Unable to convert string to uint")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to convert string to uint",
+			})
+		}
+
+		type CloneSpaceRequest struct {
+			Name            string `json:"name"`
+			IncludeWorkflow *bool  `json:"include_workflow"`
+			IncludeTasks    bool   `json:"include_tasks"`
+		}
+		req := new(CloneSpaceRequest)
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"status": "error",
+					"error":  "Invalid request body",
+				})
+			}
+		}
+
+		var source models.Space
+		err = db.First(&source, spaceId).Error
+		if err == nil && !source.IsTemplate {
+			var isMember bool
+			isMember, err = utils.IsSpaceMember(source.ID, userId, db)
+			if err == nil && !isMember {
+				err = gorm.ErrRecordNotFound
+			}
+		}
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"status": "error",
+					"error":  "space not found or user is not a member",
+				})
+			}
+			log.Warnf("Error loading space: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to clone space",
+			})
+		}
+
+		clone := models.Space{
+			Name:        req.Name,
+			CreatedBy:   userId,
+			Map:         source.Map,
+			Description: source.Description,
+			Visibility:  models.SpaceVisibilityPrivate,
+		}
+		if clone.Name == "" {
+			clone.Name = fmt.Sprintf("Copy of %s", source.Name)
+		}
+		if err := validateSpace(&clone); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  err.Error(),
+			})
+		}
+
+		includeWorkflow := req.IncludeWorkflow == nil || *req.IncludeWorkflow || req.IncludeTasks
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&clone).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.UserSpace{
+				UserID:  userId,
+				SpaceID: clone.ID,
+				Role:    models.SpaceRoleOwner,
+			}).Error; err != nil {
+				return err
+			}
+
+			if err := cloneWhiteboard(tx, source.ID, clone.ID); err != nil {
+				return err
+			}
+			if err := cloneZones(tx, source.ID, clone.ID); err != nil {
+				return err
+			}
+
+			if !includeWorkflow {
+				return nil
+			}
+
+			labelIds, err := cloneWorkflow(tx, source.ID, clone.ID)
+			if err != nil {
+				return err
+			}
+
+			if !req.IncludeTasks {
+				return nil
+			}
+			return cloneTasks(tx, source.ID, clone.ID, userId, labelIds)
+		})
+		if err != nil {
+			log.Warnf("Error cloning space: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to clone space",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Space cloned successfully",
+			"data":    clone,
+		})
+	})
+}
+
+// cloneWhiteboard copies the last saved state of the whiteboard, edits still
+// waiting for the debounced save are not included.
+func cloneWhiteboard(tx *gorm.DB, sourceId uint, cloneId uint) error {
+	var whiteboard models.SpaceWhiteboard
+	if err := tx.Where("space_id = ?", sourceId).First(&whiteboard).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return tx.Create(&models.SpaceWhiteboard{
+		SpaceID:  cloneId,
+		Elements: whiteboard.Elements,
+		AppState: whiteboard.AppState,
+	}).Error
+}
+
+func cloneZones(tx *gorm.DB, sourceId uint, cloneId uint) error {
+	var zones []models.SpaceZone
+	if err := tx.Where("space_id = ?", sourceId).Find(&zones).Error; err != nil {
+		return err
+	}
+	for _, zone := range zones {
+		copied := models.SpaceZone{
+			SpaceID: cloneId,
+			Name:    zone.Name,
+			X:       zone.X,
+			Y:       zone.Y,
+			Width:   zone.Width,
+			Height:  zone.Height,
+		}
+		if err := tx.Create(&copied).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cloneWorkflow copies the columns and labels, returning the new ID of every label.
+func cloneWorkflow(tx *gorm.DB, sourceId uint, cloneId uint) (map[uint]uint, error) {
+	var columns []models.KanbanColumn
+	if err := tx.Where("space_id = ?", sourceId).Order("position").Find(&columns).Error; err != nil {
+		return nil, err
+	}
+	for _, column := range columns {
+		copied := models.KanbanColumn{
+			SpaceID:  cloneId,
+			Name:     column.Name,
+			Color:    column.Color,
+			WIPLimit: column.WIPLimit,
+			IsDone:   column.IsDone,
+			Position: column.Position,
+		}
+		if err := tx.Create(&copied).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var labels []models.KanbanLabel
+	if err := tx.Where("space_id = ?", sourceId).Find(&labels).Error; err != nil {
+		return nil, err
+	}
+	labelIds := make(map[uint]uint, len(labels))
+	for _, label := range labels {
+		copied := models.KanbanLabel{
+			SpaceID: cloneId,
+			Name:    label.Name,
+			Color:   label.Color,
+		}
+		if err := tx.Create(&copied).Error; err != nil {
+			return nil, err
+		}
+		labelIds[label.ID] = copied.ID
+	}
+
+	return labelIds, nil
+}
+
+// cloneTasks copies tasks with their checklists, labels and subtask links.
+// Comments, watchers, assignees and history stay with the original space.
+func cloneTasks(tx *gorm.DB, sourceId uint, cloneId uint, userId uint, labelIds map[uint]uint) error {
+	var tasks []models.KanbanTasks
+	if err := tx.Preload("Labels").Where("space_id = ?", sourceId).Order("id").Find(&tasks).Error; err != nil {
+		return err
+	}
+
+	taskIds := make(map[uint]uint, len(tasks))
+	for _, task := range tasks {
+		copied := models.KanbanTasks{
+			SpaceID:     cloneId,
+			Title:       task.Title,
+			Description: task.Description,
+			Status:      task.Status,
+			Priority:    task.Priority,
+			DueDate:     task.DueDate,
+			Rank:        task.Rank,
+			CreatedBy:   userId,
+		}
+		if err := tx.Create(&copied).Error; err != nil {
+			return err
+		}
+		taskIds[task.ID] = copied.ID
+
+		for _, label := range task.Labels {
+			if err := tx.Table("kanban_task_labels").Create(map[string]interface{}{
+				"kanban_tasks_id": copied.ID,
+				"kanban_label_id": labelIds[label.ID],
+			}).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	// Parents may have been copied after their subtasks, so links are set last
+	for _, task := range tasks {
+		if task.ParentID == nil {
+			continue
+		}
+		parentId, ok := taskIds[*task.ParentID]
+		if !ok {
+			continue
+		}
+		if err := tx.Model(&models.KanbanTasks{}).Where("id = ?", taskIds[task.ID]).Update("parent_id", parentId).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(taskIds) == 0 {
+		return nil
+	}
+
+	sourceTaskIds := make([]uint, 0, len(taskIds))
+	for id := range taskIds {
+		sourceTaskIds = append(sourceTaskIds, id)
+	}
+
+	var items []models.KanbanChecklistItem
+	if err := tx.Where("task_id IN ?", sourceTaskIds).Find(&items).Error; err != nil {
+		return err
+	}
+	for _, item := range items {
+		copied := models.KanbanChecklistItem{
+			TaskID:   taskIds[item.TaskID],
+			Text:     item.Text,
+			Done:     item.Done,
+			Position: item.Position,
+		}
+		if err := tx.Create(&copied).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
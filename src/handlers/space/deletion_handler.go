@@ -0,0 +1,238 @@
+package space
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/bhav-07/haven/config"
+	"github.com/bhav-07/haven/models"
+	"github.com/bhav-07/haven/redis"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+const (
+	purgeInterval = time.Hour
+	purgeLockKey  = "space:purge:lock"
+)
+
+// Soft deleted rows that belong to a space through their space_id
+var spaceChildren = []interface{}{
+	&models.UserSpace{},
+	&models.SpaceInvite{},
+	&models.SpaceWhiteboard{},
+	&models.SpaceChatMessage{},
+	&models.DirectMessage{},
+	&models.SpaceZone{},
+	&models.KanbanColumn{},
+	&models.KanbanLabel{},
+	&models.KanbanTasks{},
+}
+
+// Soft deleted rows that belong to a space through one of its tasks
+var taskChildren = []interface{}{
+	&models.KanbanComment{},
+	&models.KanbanChecklistItem{},
+}
+
+func deletionHandlers(route fiber.Router, db *gorm.DB) {
+	go purgeDeletedSpaces(db)
+
+	// Brings back a deleted space with everything deleted alongside it, as long
+	// as the retention window has not passed. Only the owner can restore.
+	route.Post("/space/:id/restore", func(c *fiber.Ctx) error {
+		userId, ok := c.Locals("userId").(uint)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to get user details. Please login again",
+			})
+		}
+
+		spaceId, err := strconv.ParseUint(c.Params("id"), 10, 64)
+		if err != nil {
+			log.Warn("Unable to convert string to uint")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to convert string to uint",
+			})
+		}
+
+		var space models.Space
+		if err := db.Unscoped().Where("deleted_at IS NOT NULL").First(&space, spaceId).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"status": "error",
+					"error":  "Deleted space not found",
+				})
+			}
+			log.Warnf("Error loading deleted space: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to restore space",
+			})
+		}
+
+		if space.CreatedBy != userId {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Only the owner of this space can restore it",
+			})
+		}
+
+		if time.Since(space.DeletedAt.Time) > config.GetSpaceRetention() {
+			return c.Status(fiber.StatusGone).JSON(fiber.Map{
+				"status": "error",
+				"error":  "This space can no longer be restored",
+			})
+		}
+
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return restoreSpace(tx, space)
+		}); err != nil {
+			log.Warnf("Error restoring space: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to restore space",
+			})
+		}
+		space.DeletedAt = gorm.DeletedAt{}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Space restored successfully",
+			"data":    space,
+		})
+	})
+}
+
+// softDeleteSpace deletes the space and all its live children at deletedAt.
+// Rows deleted earlier keep their own timestamp and are not restored with it.
+func softDeleteSpace(tx *gorm.DB, spaceId uint, deletedAt time.Time) error {
+	// Task children go first, their subquery only sees tasks that are not deleted yet
+	tasks := tx.Model(&models.KanbanTasks{}).Select("id").Where("space_id = ?", spaceId)
+	for _, model := range taskChildren {
+		if err := tx.Model(model).Where("task_id IN (?)", tasks).Update("deleted_at", deletedAt).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, model := range spaceChildren {
+		if err := tx.Model(model).Where("space_id = ?", spaceId).Update("deleted_at", deletedAt).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&models.Space{}).Where("id = ?", spaceId).Update("deleted_at", deletedAt).Error
+}
+
+func restoreSpace(tx *gorm.DB, space models.Space) error {
+	deletedAt := space.DeletedAt.Time
+
+	tasks := tx.Unscoped().Model(&models.KanbanTasks{}).Select("id").Where("space_id = ?", space.ID)
+	for _, model := range taskChildren {
+		if err := tx.Unscoped().Model(model).
+			Where("task_id IN (?) AND deleted_at = ?", tasks, deletedAt).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, model := range spaceChildren {
+		if err := tx.Unscoped().Model(model).
+			Where("space_id = ? AND deleted_at = ?", space.ID, deletedAt).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Unscoped().Model(&models.Space{}).Where("id = ?", space.ID).Update("deleted_at", nil).Error
+}
+
+// purgeSpace removes every row of a deleted space for good.
+func purgeSpace(tx *gorm.DB, spaceId uint) error {
+	tasks := tx.Unscoped().Model(&models.KanbanTasks{}).Select("id").Where("space_id = ?", spaceId)
+	comments := tx.Unscoped().Model(&models.KanbanComment{}).Select("id").Where("task_id IN (?)", tasks)
+	chatMessages := tx.Unscoped().Model(&models.SpaceChatMessage{}).Select("id").Where("space_id = ?", spaceId)
+
+	joinTables := []struct {
+		table  string
+		column string
+		ids    *gorm.DB
+	}{
+		{"kanban_comment_mentions", "kanban_comment_id", comments},
+		{"kanban_task_watchers", "kanban_tasks_id", tasks},
+		{"kanban_task_labels", "kanban_tasks_id", tasks},
+		{"space_chat_reactions", "message_id", chatMessages},
+	}
+	for _, join := range joinTables {
+		if err := tx.Table(join.table).Where(join.column+" IN (?)", join.ids).Delete(map[string]interface{}{}).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, model := range taskChildren {
+		if err := tx.Unscoped().Where("task_id IN (?)", tasks).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Where("space_id = ?", spaceId).Delete(&models.KanbanActivity{}).Error; err != nil {
+		return err
+	}
+
+	for _, model := range spaceChildren {
+		if err := tx.Unscoped().Where("space_id = ?", spaceId).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Unscoped().Delete(&models.Space{}, spaceId).Error
+}
+
+// purgeDeletedSpaces periodically purges spaces deleted longer ago than the
+// retention window. Every replica runs it, a Redis lock lets only one purge at a time.
+func purgeDeletedSpaces(db *gorm.DB) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purgeExpiredSpaces(db)
+		<-ticker.C
+	}
+}
+
+func purgeExpiredSpaces(db *gorm.DB) {
+	ctx := context.Background()
+
+	acquired, err := redis.RedisClient.SetNX(ctx, purgeLockKey, 1, purgeInterval/2).Result()
+	if err != nil {
+		log.Errorf("Failed to acquire space purge lock: %v", err)
+		return
+	}
+	if !acquired {
+		return
+	}
+	defer redis.RedisClient.Del(ctx, purgeLockKey)
+
+	var spaceIds []uint
+	if err := db.Unscoped().Model(&models.Space{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", time.Now().Add(-config.GetSpaceRetention())).
+		Pluck("id", &spaceIds).Error; err != nil {
+		log.Warnf("Error listing expired spaces: %v", err.Error())
+		return
+	}
+
+	for _, spaceId := range spaceIds {
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return purgeSpace(tx, spaceId)
+		}); err != nil {
+			log.Warnf("Error purging space %d: %v", spaceId, err.Error())
+			continue
+		}
+		log.Infof("Purged deleted space %d", spaceId)
+	}
+}
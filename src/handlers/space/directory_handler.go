@@ -0,0 +1,324 @@
+package space
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/bhav-07/haven/middleware"
+	"github.com/bhav-07/haven/models"
+	"github.com/bhav-07/haven/redis"
+	"github.com/bhav-07/haven/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultDirectoryLimit = 20
+	maxDirectoryLimit     = 50
+)
+
+type directorySpace struct {
+	ID          uint                   `json:"id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Map         string                 `json:"map"`
+	Visibility  models.SpaceVisibility `json:"visibility"`
+	MemberCount int64                  `json:"member_count"`
+	IsMember    bool                   `json:"is_member"`
+	Online      int64                  `json:"online" gorm:"-"`
+}
+
+func directoryHandlers(route fiber.Router, db *gorm.DB) {
+	// Lists the spaces the caller can discover: public ones and organization
+	// ones owned by someone with the same email domain, unless that domain is a
+	// public mail provider. Archived spaces are left out.
+	route.Get("/spaces/directory", func(c *fiber.Ctx) error {
+		userId, ok := c.Locals("userId").(uint)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to get user details. Please login again",
+			})
+		}
+
+		user, err := utils.GetUserfromID(userId, db)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status": "error",
+				"error":  "User not found",
+			})
+		}
+
+		limit := c.QueryInt("limit", defaultDirectoryLimit)
+		if limit <= 0 || limit > maxDirectoryLimit {
+			limit = defaultDirectoryLimit
+		}
+		page := c.QueryInt("page", 1)
+		if page < 1 {
+			page = 1
+		}
+
+		query := db.Model(&models.Space{}).
+			Joins("JOIN users owners ON owners.id = spaces.created_by").
+			Where("spaces.archived_at IS NULL")
+		if domain := utils.OrganizationDomain(user.Email); domain != "" {
+			query = query.Where("spaces.visibility = ? OR (spaces.visibility = ? AND LOWER(split_part(owners.email, '@', 2)) = ?)",
+				models.SpaceVisibilityPublic, models.SpaceVisibilityOrganization, domain)
+		} else {
+			// Users of public mail providers belong to no organization
+			query = query.Where("spaces.visibility = ?", models.SpaceVisibilityPublic)
+		}
+
+		if search := strings.TrimSpace(c.Query("q")); search != "" {
+			pattern := "%" + utils.EscapeLike(search) + "%"
+			query = query.Where("(spaces.name ILIKE ? OR spaces.description ILIKE ?)", pattern, pattern)
+		}
+
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			log.Warnf("Error counting directory spaces: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to fetch spaces",
+			})
+		}
+
+		spaces := []directorySpace{}
+		if err := query.
+			Select(`spaces.id, spaces.name, spaces.description, spaces.map, spaces.visibility,
+				(SELECT COUNT(*) FROM user_spaces WHERE user_spaces.space_id = spaces.id AND user_spaces.deleted_at IS NULL) AS member_count,
+				EXISTS (SELECT 1 FROM user_spaces WHERE user_spaces.space_id = spaces.id AND user_spaces.user_id = ? AND user_spaces.deleted_at IS NULL) AS is_member`, userId).
+			Order("member_count DESC, spaces.id").
+			Limit(limit).
+			Offset((page - 1) * limit).
+			Scan(&spaces).Error; err != nil {
+			log.Warnf("Error fetching directory spaces: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to fetch spaces",
+			})
+		}
+
+		spaceIds := make([]uint, len(spaces))
+		for i, space := range spaces {
+			spaceIds[i] = space.ID
+		}
+		online, err := redis.PresenceCounts(redis.RedisClient, spaceIds)
+		if err != nil {
+			log.Errorf("Error reading presence: %v", err)
+		}
+		for i := range spaces {
+			spaces[i].Online = online[spaces[i].ID]
+		}
+
+		return c.JSON(fiber.Map{
+			"status": "success",
+			"data":   spaces,
+			"page":   page,
+			"limit":  limit,
+			"total":  total,
+		})
+	})
+
+	// Joins a public or organization space as a member. Link-only spaces are
+	// joined through their share link, never by ID.
+	route.Post("/space/:id/join", func(c *fiber.Ctx) error {
+		userId, ok := c.Locals("userId").(uint)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to get user details. Please login again",
+			})
+		}
+
+		spaceId, err := strconv.ParseUint(c.Params("id"), 10, 64)
+		if err != nil {
+			log.Warn("Unable to convert string to uint")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to convert string to uint",
+			})
+		}
+
+		user, err := utils.GetUserfromID(userId, db)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status": "error",
+				"error":  "User not found",
+			})
+		}
+
+		var space models.Space
+		var owner models.User
+		err = db.First(&space, spaceId).Error
+		if err == nil {
+			err = db.First(&owner, space.CreatedBy).Error
+		}
+		if err == nil && !canSelfJoin(space, owner, user) {
+			// Private and link-only spaces answer like missing ones
+			err = gorm.ErrRecordNotFound
+		}
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"status": "error",
+					"error":  "Space not found or it needs an invite",
+				})
+			}
+			log.Warnf("Error loading space: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to add user to the space",
+			})
+		}
+
+		return joinSpace(c, db, space, user)
+	})
+
+	// Creates the share link of a link-only space, replacing the previous one.
+	// The token is only returned here, the link is built by the client.
+	route.Post("/space/:id/share-link", middleware.SpacePermission(db, middleware.SpaceFromParam("id"), models.SpacePermissionManageMembers), func(c *fiber.Ctx) error {
+		space, _ := middleware.CurrentSpace(c)
+
+		if space.Visibility != models.SpaceVisibilityLinkOnly {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Only link-only spaces have a share link",
+			})
+		}
+
+		token, err := newInviteToken()
+		if err != nil {
+			log.Warnf("Error generating share token: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to create share link",
+			})
+		}
+
+		if err := db.Model(&space).Update("share_token_hash", hashInviteToken(token)).Error; err != nil {
+			log.Warnf("Error saving share token: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to create share link",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Share link created successfully",
+			"token":   token,
+		})
+	})
+
+	route.Delete("/space/:id/share-link", middleware.SpacePermission(db, middleware.SpaceFromParam("id"), models.SpacePermissionManageMembers), func(c *fiber.Ctx) error {
+		space, _ := middleware.CurrentSpace(c)
+
+		if err := db.Model(&space).Update("share_token_hash", nil).Error; err != nil {
+			log.Warnf("Error revoking share token: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to revoke share link",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Share link revoked successfully",
+		})
+	})
+
+	// Joins a link-only space through its share link, as a member.
+	route.Post("/space/share/:token", func(c *fiber.Ctx) error {
+		userId, ok := c.Locals("userId").(uint)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to get user details. Please login again",
+			})
+		}
+
+		user, err := utils.GetUserfromID(userId, db)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status": "error",
+				"error":  "User not found",
+			})
+		}
+
+		var space models.Space
+		if err := db.Where("share_token_hash = ? AND visibility = ?", hashInviteToken(c.Params("token")), models.SpaceVisibilityLinkOnly).
+			First(&space).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"status": "error",
+					"error":  "Share link not found or revoked",
+				})
+			}
+			log.Warnf("Error loading space: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to add user to the space",
+			})
+		}
+
+		return joinSpace(c, db, space, user)
+	})
+}
+
+// joinSpace adds the user to a space they are allowed to join on their own, as a member.
+func joinSpace(c *fiber.Ctx, db *gorm.DB, space models.Space, user models.User) error {
+	if space.ArchivedAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status": "error",
+			"error":  "This space is archived",
+		})
+	}
+
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserSpace{
+		UserID:  user.ID,
+		SpaceID: space.ID,
+		Role:    models.SpaceRoleMember,
+	})
+	if result.Error != nil {
+		log.Warnf("Error joining space: %v", result.Error.Error())
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Failed to add user to the space",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status": "error",
+			"error":  "User is already a member of this space",
+		})
+	}
+
+	if err := redis.PublishSpaceEvent(redis.RedisClient, space.ID, "member_joined", map[string]interface{}{
+		"user_id":  user.ID,
+		"nickname": user.Nickname,
+		"role":     models.SpaceRoleMember,
+	}); err != nil {
+		log.Errorf("Failed to publish space event: %v", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "User successfully added to the space",
+		"data":    space,
+	})
+}
+
+func canSelfJoin(space models.Space, owner models.User, user models.User) bool {
+	switch space.Visibility {
+	case models.SpaceVisibilityPublic:
+		return true
+	case models.SpaceVisibilityOrganization:
+		domain := utils.OrganizationDomain(user.Email)
+		return domain != "" && domain == utils.OrganizationDomain(owner.Email)
+	}
+	return false
+}
@@ -0,0 +1,288 @@
+package space
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bhav-07/haven/middleware"
+	"github.com/bhav-07/haven/models"
+	"github.com/bhav-07/haven/redis"
+	"github.com/bhav-07/haven/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultInviteHours = 7 * 24
+
+func inviteHandlers(route fiber.Router, db *gorm.DB) {
+	route.Post("/space/join/:token", func(c *fiber.Ctx) error {
+		userId, ok := c.Locals("userId").(uint)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to get user details. Please login again",
+			})
+		}
+
+		user, err := utils.GetUserfromID(userId, db)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status": "error",
+				"error":  "User not found",
+			})
+		}
+
+		var invite models.SpaceInvite
+		var space models.Space
+		err = db.Transaction(func(tx *gorm.DB) error {
+			// Locking the invite keeps concurrent redemptions within max_uses
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("token_hash = ?", hashInviteToken(c.Params("token"))).
+				First(&invite).Error; err != nil {
+				return err
+			}
+
+			if invite.ExpiresAt != nil && time.Now().After(*invite.ExpiresAt) {
+				return fiber.NewError(fiber.StatusGone, "This invite has expired")
+			}
+			if invite.MaxUses > 0 && invite.Uses >= invite.MaxUses {
+				return fiber.NewError(fiber.StatusGone, "This invite has already been used")
+			}
+			if invite.Email != "" && !strings.EqualFold(invite.Email, user.Email) {
+				return fiber.NewError(fiber.StatusForbidden, "This invite was sent to a different email address")
+			}
+
+			if err := tx.First(&space, invite.SpaceID).Error; err != nil {
+				return err
+			}
+
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserSpace{
+				UserID:  user.ID,
+				SpaceID: space.ID,
+				Role:    invite.Role,
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fiber.NewError(fiber.StatusConflict, "User is already a member of this space")
+			}
+
+			return tx.Model(&invite).Update("uses", gorm.Expr("uses + 1")).Error
+		})
+		if err != nil {
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				return c.Status(fiberErr.Code).JSON(fiber.Map{
+					"status": "error",
+					"error":  fiberErr.Message,
+				})
+			}
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"status": "error",
+					"error":  "Invite not found or revoked",
+				})
+			}
+			log.Warnf("Error redeeming invite: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to add user to the space",
+			})
+		}
+
+		if err := redis.PublishSpaceEvent(redis.RedisClient, space.ID, "member_joined", map[string]interface{}{
+			"user_id":  user.ID,
+			"nickname": user.Nickname,
+			"role":     invite.Role,
+		}); err != nil {
+			log.Errorf("Failed to publish space event: %v", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "User successfully added to the space",
+			"data":    space,
+		})
+	})
+
+	route.Get("/space/:id/invites", middleware.SpacePermission(db, middleware.SpaceFromParam("id"), models.SpacePermissionManageMembers), func(c *fiber.Ctx) error {
+		space, _ := middleware.CurrentSpace(c)
+
+		var invites []models.SpaceInvite
+		if err := db.Where("space_id = ?", space.ID).
+			Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+			Where("max_uses = 0 OR uses < max_uses").
+			Order("created_at DESC").
+			Find(&invites).Error; err != nil {
+			log.Warnf("Error fetching invites: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to fetch invites",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"status": "success",
+			"data":   invites,
+		})
+	})
+
+	// Creates an invite. The token is only returned here, the link is built by the client.
+	// Callers can only hand out roles below their own.
+	route.Post("/space/:id/invites", middleware.SpacePermission(db, middleware.SpaceFromParam("id"), models.SpacePermissionManageMembers), func(c *fiber.Ctx) error {
+		userId, _ := c.Locals("userId").(uint)
+		space, _ := middleware.CurrentSpace(c)
+
+		type CreateInviteRequest struct {
+			Role    models.SpaceRole `json:"role"`
+			Email   string           `json:"email"`
+			MaxUses int              `json:"max_uses"`
+			// 0 creates an invite that never expires
+			ExpiresInHours *int `json:"expires_in_hours"`
+		}
+		req := new(CreateInviteRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid request body",
+			})
+		}
+
+		if req.Role == "" {
+			req.Role = models.SpaceRoleMember
+		}
+		if !req.Role.IsValid() || req.Role == models.SpaceRoleOwner {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid role. Valid roles are: admin, member, guest",
+			})
+		}
+		if !middleware.CurrentSpaceRole(c).Outranks(req.Role) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status": "error",
+				"error":  "You can only invite people with a role below your own",
+			})
+		}
+
+		if req.MaxUses < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "max_uses cannot be negative",
+			})
+		}
+
+		invite := models.SpaceInvite{
+			SpaceID:   space.ID,
+			CreatedBy: userId,
+			Role:      req.Role,
+			Email:     strings.ToLower(strings.TrimSpace(req.Email)),
+			MaxUses:   req.MaxUses,
+		}
+
+		if invite.Email != "" {
+			if !strings.Contains(invite.Email, "@") {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"status": "error",
+					"error":  "Invalid email address",
+				})
+			}
+			// An invite bound to an email is meant for a single person
+			invite.MaxUses = 1
+		}
+
+		hours := defaultInviteHours
+		if req.ExpiresInHours != nil {
+			hours = *req.ExpiresInHours
+		}
+		if hours < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "expires_in_hours cannot be negative",
+			})
+		}
+		if hours > 0 {
+			expiresAt := time.Now().Add(time.Duration(hours) * time.Hour)
+			invite.ExpiresAt = &expiresAt
+		}
+
+		token, err := newInviteToken()
+		if err != nil {
+			log.Warnf("Error generating invite token: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to create invite",
+			})
+		}
+		invite.TokenHash = hashInviteToken(token)
+
+		if err := db.Create(&invite).Error; err != nil {
+			log.Warnf("Error creating invite: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to create invite",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Invite created successfully",
+			"data":    invite,
+			"token":   token,
+		})
+	})
+
+	route.Delete("/space/:id/invites/:inviteID", middleware.SpacePermission(db, middleware.SpaceFromParam("id"), models.SpacePermissionManageMembers), func(c *fiber.Ctx) error {
+		space, _ := middleware.CurrentSpace(c)
+
+		inviteId, err := strconv.ParseUint(c.Params("inviteID"), 10, 64)
+		if err != nil {
+			log.Warn("Unable to convert string to uint")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to convert string to uint",
+			})
+		}
+
+		result := db.Where("space_id = ?", space.ID).Delete(&models.SpaceInvite{}, inviteId)
+		if result.Error != nil {
+			log.Warnf("Error revoking invite: %v", result.Error.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to revoke invite",
+			})
+		}
+		if result.RowsAffected == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invite not found",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Invite revoked successfully",
+		})
+	})
+}
+
+func newInviteToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
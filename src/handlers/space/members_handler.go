@@ -0,0 +1,336 @@
+package space
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/bhav-07/haven/middleware"
+	"github.com/bhav-07/haven/models"
+	"github.com/bhav-07/haven/redis"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type spaceMember struct {
+	ID        uint              `json:"id"`
+	Name      string            `json:"name"`
+	Nickname  string            `json:"nickname"`
+	Character string            `json:"character"`
+	Status    models.UserStatus `json:"status"`
+	Role      models.SpaceRole  `json:"role"`
+	JoinedAt  *time.Time        `json:"joined_at"`
+}
+
+func memberHandlers(route fiber.Router, db *gorm.DB) {
+	route.Get("/space/:id/members", middleware.SpaceMember(db, middleware.SpaceFromParam("id")), func(c *fiber.Ctx) error {
+		space, _ := middleware.CurrentSpace(c)
+
+		var members []spaceMember
+		if err := db.Table("user_spaces").
+			Select("users.id, users.name, users.nickname, users.character, users.status, user_spaces.role, user_spaces.created_at AS joined_at").
+			Joins("JOIN users ON users.id = user_spaces.user_id AND users.deleted_at IS NULL").
+			Where("user_spaces.space_id = ? AND user_spaces.deleted_at IS NULL", space.ID).
+			Order("users.nickname").
+			Scan(&members).Error; err != nil {
+			log.Warnf("Error fetching space members: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to fetch space members",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"status": "success",
+			"data":   members,
+		})
+	})
+
+	// Promotes or demotes a member. Callers can only change members ranked
+	// below them, to a role ranked below them, so only the owner names admins.
+	route.Patch("/space/:id/members/:userID", middleware.SpacePermission(db, middleware.SpaceFromParam("id"), models.SpacePermissionManageMembers), func(c *fiber.Ctx) error {
+		userId, _ := c.Locals("userId").(uint)
+		space, _ := middleware.CurrentSpace(c)
+		callerRole := middleware.CurrentSpaceRole(c)
+
+		memberId, err := strconv.ParseUint(c.Params("userID"), 10, 64)
+		if err != nil {
+			log.Warn("Unable to convert string to uint")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to convert string to uint",
+			})
+		}
+
+		type UpdateRoleRequest struct {
+			Role models.SpaceRole `json:"role"`
+		}
+		req := new(UpdateRoleRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid request body",
+			})
+		}
+
+		if !req.Role.IsValid() {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid role. Valid roles are: admin, member, guest",
+			})
+		}
+		if req.Role == models.SpaceRoleOwner {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Use the transfer endpoint to hand over ownership",
+			})
+		}
+		if uint(memberId) == userId {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "You cannot change your own role",
+			})
+		}
+
+		var membership models.UserSpace
+		if err := db.Where("space_id = ? AND user_id = ?", space.ID, memberId).First(&membership).Error; err != nil {
+			return memberLookupError(c, err)
+		}
+
+		if !callerRole.Outranks(membership.Role) || !callerRole.Outranks(req.Role) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status": "error",
+				"error":  "You can only manage members and roles below your own",
+			})
+		}
+
+		if err := db.Model(&membership).Update("role", req.Role).Error; err != nil {
+			log.Warnf("Error updating member role: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to update member role",
+			})
+		}
+
+		publishRoleChanged(space.ID, membership.UserID, req.Role)
+		if err := redis.PublishSpaceEvent(redis.RedisClient, space.ID, "member_role_changed", map[string]interface{}{
+			"user_id": membership.UserID,
+			"role":    req.Role,
+		}); err != nil {
+			log.Errorf("Failed to publish space event: %v", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Member role updated successfully",
+			"data":    membership,
+		})
+	})
+
+	route.Post("/space/:id/leave", middleware.SpaceMember(db, middleware.SpaceFromParam("id")), func(c *fiber.Ctx) error {
+		userId, _ := c.Locals("userId").(uint)
+		space, _ := middleware.CurrentSpace(c)
+
+		if middleware.CurrentSpaceRole(c) == models.SpaceRoleOwner {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Transfer ownership of the space before leaving it",
+			})
+		}
+
+		if err := removeMember(db, space.ID, userId, "member_left"); err != nil {
+			return memberLookupError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "You left the space",
+		})
+	})
+
+	// Removes a member ranked below the caller and drops their live connections.
+	route.Delete("/space/:id/members/:userID", middleware.SpacePermission(db, middleware.SpaceFromParam("id"), models.SpacePermissionManageMembers), func(c *fiber.Ctx) error {
+		userId, _ := c.Locals("userId").(uint)
+		space, _ := middleware.CurrentSpace(c)
+
+		memberId, err := strconv.ParseUint(c.Params("userID"), 10, 64)
+		if err != nil {
+			log.Warn("Unable to convert string to uint")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to convert string to uint",
+			})
+		}
+
+		if uint(memberId) == userId {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Use the leave endpoint to leave a space",
+			})
+		}
+
+		var membership models.UserSpace
+		if err := db.Where("space_id = ? AND user_id = ?", space.ID, memberId).First(&membership).Error; err != nil {
+			return memberLookupError(c, err)
+		}
+
+		if !middleware.CurrentSpaceRole(c).Outranks(membership.Role) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status": "error",
+				"error":  "You can only manage members and roles below your own",
+			})
+		}
+
+		if err := removeMember(db, space.ID, membership.UserID, "member_removed"); err != nil {
+			return memberLookupError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Member removed successfully",
+		})
+	})
+
+	// Hands the space over to another member, the previous owner becomes an admin.
+	route.Post("/space/:id/transfer", middleware.SpacePermission(db, middleware.SpaceFromParam("id"), models.SpacePermissionTransferOwnership), func(c *fiber.Ctx) error {
+		userId, _ := c.Locals("userId").(uint)
+		space, _ := middleware.CurrentSpace(c)
+
+		type TransferRequest struct {
+			UserID uint `json:"user_id"`
+		}
+		req := new(TransferRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid request body",
+			})
+		}
+
+		if req.UserID == 0 || req.UserID == userId {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Choose another member of the space as the new owner",
+			})
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			// Locking the space serializes concurrent transfers
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&space, space.ID).Error; err != nil {
+				return err
+			}
+			if space.CreatedBy != userId {
+				return fiber.NewError(fiber.StatusForbidden, "Only the owner of this space can transfer it")
+			}
+
+			var membership models.UserSpace
+			if err := tx.Where("space_id = ? AND user_id = ?", space.ID, req.UserID).First(&membership).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Model(&membership).Update("role", models.SpaceRoleOwner).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.UserSpace{}).
+				Where("space_id = ? AND user_id = ?", space.ID, userId).
+				Update("role", models.SpaceRoleAdmin).Error; err != nil {
+				return err
+			}
+
+			space.CreatedBy = req.UserID
+			return tx.Model(&space).Update("created_by", req.UserID).Error
+		})
+		if err != nil {
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				return c.Status(fiberErr.Code).JSON(fiber.Map{
+					"status": "error",
+					"error":  fiberErr.Message,
+				})
+			}
+			return memberLookupError(c, err)
+		}
+
+		publishRoleChanged(space.ID, req.UserID, models.SpaceRoleOwner)
+		publishRoleChanged(space.ID, userId, models.SpaceRoleAdmin)
+		if err := redis.PublishSpaceEvent(redis.RedisClient, space.ID, "ownership_transferred", map[string]interface{}{
+			"owner_id":          req.UserID,
+			"previous_owner_id": userId,
+		}); err != nil {
+			log.Errorf("Failed to publish space event: %v", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Ownership transferred successfully",
+		})
+	})
+}
+
+// publishRoleChanged tells the live connections of the user on every replica
+// about their new role, so permissions checked per message follow it.
+func publishRoleChanged(spaceId uint, userId uint, role models.SpaceRole) {
+	if err := redis.PublishLifecycleEvent(redis.RedisClient, redis.LifecycleEvent{
+		Type:    redis.LifecycleRoleChanged,
+		SpaceID: spaceId,
+		UserID:  userId,
+		Role:    role,
+	}); err != nil {
+		log.Errorf("Failed to publish lifecycle event: %v", err)
+	}
+}
+
+// removeMember deletes the membership along with the member's task watches,
+// then disconnects them from the space on every replica.
+func removeMember(db *gorm.DB, spaceId uint, userId uint, eventType string) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Memberships are only soft deleted along with their space, a removed member can be invited again
+		result := tx.Unscoped().Where("space_id = ? AND user_id = ?", spaceId, userId).Delete(&models.UserSpace{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return tx.Table("kanban_task_watchers").
+			Where("user_id = ? AND kanban_tasks_id IN (?)", userId, tx.Model(&models.KanbanTasks{}).Select("id").Where("space_id = ?", spaceId)).
+			Delete(map[string]interface{}{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := redis.PublishLifecycleEvent(redis.RedisClient, redis.LifecycleEvent{
+		Type:    redis.LifecycleMemberRemoved,
+		SpaceID: spaceId,
+		UserID:  userId,
+	}); err != nil {
+		log.Errorf("Failed to publish lifecycle event: %v", err)
+	}
+
+	if err := redis.PublishSpaceEvent(redis.RedisClient, spaceId, eventType, map[string]interface{}{
+		"user_id": userId,
+	}); err != nil {
+		log.Errorf("Failed to publish space event: %v", err)
+	}
+
+	return nil
+}
+
+func memberLookupError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status": "error",
+			"error":  "User is not a member of this space",
+		})
+	}
+
+	log.Warnf("Error updating space members: %v", err.Error())
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"status": "error",
+		"error":  "Failed to update space members",
+	})
+}
@@ -0,0 +1,178 @@
+package space
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bhav-07/haven/maps"
+	"github.com/bhav-07/haven/middleware"
+	"github.com/bhav-07/haven/models"
+	"github.com/bhav-07/haven/redis"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+func settingsHandlers(route fiber.Router, db *gorm.DB) {
+	route.Patch("/space/:id", middleware.SpacePermission(db, middleware.SpaceFromParam("id"), models.SpacePermissionManageSpace), func(c *fiber.Ctx) error {
+		space, _ := middleware.CurrentSpace(c)
+
+		type UpdateSpaceRequest struct {
+			Name        *string                 `json:"name"`
+			Map         *string                 `json:"map"`
+			Description *string                 `json:"description"`
+			Visibility  *models.SpaceVisibility `json:"visibility"`
+			// Templates can be cloned by anyone, not just members
+			IsTemplate *bool `json:"is_template"`
+		}
+		req := new(UpdateSpaceRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid request body",
+			})
+		}
+
+		var fields []string
+		if req.Name != nil {
+			space.Name = *req.Name
+			fields = append(fields, "name")
+		}
+		if req.Map != nil {
+			space.Map = *req.Map
+			fields = append(fields, "map")
+		}
+		if req.Description != nil {
+			space.Description = *req.Description
+			fields = append(fields, "description")
+		}
+		if req.Visibility != nil {
+			space.Visibility = *req.Visibility
+			fields = append(fields, "visibility")
+			// Share links only work for link-only spaces, switching away revokes it
+			if space.Visibility != models.SpaceVisibilityLinkOnly {
+				space.ShareTokenHash = nil
+				fields = append(fields, "share_token_hash")
+			}
+		}
+		if req.IsTemplate != nil {
+			space.IsTemplate = *req.IsTemplate
+			fields = append(fields, "is_template")
+		}
+
+		if len(fields) == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "No fields to update",
+			})
+		}
+
+		if err := validateSpace(&space); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  err.Error(),
+			})
+		}
+
+		if err := db.Model(&space).Select(fields).Updates(&space).Error; err != nil {
+			log.Warnf("Error updating space: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to update space",
+			})
+		}
+
+		return publishSpaceUpdated(c, space, "Space updated successfully")
+	})
+
+	route.Post("/space/:id/archive", middleware.SpacePermission(db, middleware.SpaceFromParam("id"), models.SpacePermissionManageSpace), func(c *fiber.Ctx) error {
+		return setArchived(c, db, true)
+	})
+
+	route.Post("/space/:id/unarchive", middleware.SpacePermission(db, middleware.SpaceFromParam("id"), models.SpacePermissionManageSpace), func(c *fiber.Ctx) error {
+		return setArchived(c, db, false)
+	})
+}
+
+// setArchived archives or restores the current space. Archived spaces keep
+// their members and can still be visited, but nothing in them can be edited.
+func setArchived(c *fiber.Ctx, db *gorm.DB, archived bool) error {
+	space, _ := middleware.CurrentSpace(c)
+
+	if (space.ArchivedAt != nil) == archived {
+		message := "Space is not archived"
+		if archived {
+			message = "Space is already archived"
+		}
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status": "error",
+			"error":  message,
+		})
+	}
+
+	space.ArchivedAt = nil
+	event := redis.LifecycleSpaceUnarchived
+	if archived {
+		now := time.Now()
+		space.ArchivedAt = &now
+		event = redis.LifecycleSpaceArchived
+	}
+
+	if err := db.Model(&space).Update("archived_at", space.ArchivedAt).Error; err != nil {
+		log.Warnf("Error archiving space: %v", err.Error())
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Failed to update space",
+		})
+	}
+
+	if err := redis.PublishLifecycleEvent(redis.RedisClient, redis.LifecycleEvent{
+		Type:    event,
+		SpaceID: space.ID,
+	}); err != nil {
+		log.Errorf("Failed to publish lifecycle event: %v", err)
+	}
+
+	if archived {
+		return publishSpaceUpdated(c, space, "Space archived successfully")
+	}
+	return publishSpaceUpdated(c, space, "Space unarchived successfully")
+}
+
+func validateSpace(space *models.Space) error {
+	space.Name = strings.TrimSpace(space.Name)
+	if space.Name == "" {
+		return errors.New("Space name cannot be empty")
+	}
+
+	if space.Map == "" {
+		space.Map = maps.DefaultMap
+	}
+	if !maps.Exists(space.Map) {
+		return errors.New("Unknown map")
+	}
+
+	if space.Visibility == "" {
+		space.Visibility = models.SpaceVisibilityPrivate
+	}
+	if !space.Visibility.IsValid() {
+		return errors.New("Invalid visibility. Valid values are: private, link_only, organization, public")
+	}
+
+	return nil
+}
+
+func publishSpaceUpdated(c *fiber.Ctx, space models.Space, message string) error {
+	if err := redis.PublishSpaceEvent(redis.RedisClient, space.ID, "space_updated", map[string]interface{}{
+		"space": space,
+	}); err != nil {
+		log.Errorf("Failed to publish space event: %v", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": message,
+		"data":    space,
+	})
+}
@@ -2,10 +2,10 @@ package space
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
-	"strconv"
+	"time"
 
+	"github.com/bhav-07/haven/middleware"
 	"github.com/bhav-07/haven/models"
 	"github.com/bhav-07/haven/redis"
 	"github.com/bhav-07/haven/utils"
@@ -17,7 +17,7 @@ import (
 
 func SpaceHandlers(route fiber.Router, db *gorm.DB) {
 
-	spaceServer, err := redis.NewSpaceServer()
+	spaceServer, err := redis.NewSpaceServer(db)
 	if err != nil {
 		log.Error("Unable to create a space server: %v", err.Error())
 	}
@@ -40,16 +40,36 @@ func SpaceHandlers(route fiber.Router, db *gorm.DB) {
 			})
 		}
 
-		newSpace.CreatedBy = user.ID
-		newSpace.Members = []models.User{user}
 		if err := json.Unmarshal(c.Body(), &newSpace); err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"status": "error",
 				"error":  err.Error(),
 			})
 		}
+		newSpace.CreatedBy = user.ID
+		newSpace.Members = nil
+		newSpace.ArchivedAt = nil
+		newSpace.IsTemplate = false
+
+		if err := validateSpace(newSpace); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  err.Error(),
+			})
+		}
 
-		if err := db.Create(&newSpace).Error; err != nil {
+		// The creator joins as the owner of the space
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&newSpace).Error; err != nil {
+				return err
+			}
+			return tx.Create(&models.UserSpace{
+				UserID:  user.ID,
+				SpaceID: newSpace.ID,
+				Role:    models.SpaceRoleOwner,
+			}).Error
+		})
+		if err != nil {
 			log.Warn("Error creating user: %v", err.Error())
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"status": "error",
@@ -63,147 +83,82 @@ func SpaceHandlers(route fiber.Router, db *gorm.DB) {
 		})
 	})
 
-	route.Get("/space/:id", func(c *fiber.Ctx) error {
-		userId, ok := c.Locals("userId").(uint)
-		if !ok {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"status": "error",
-				"error":  "Unable to get user details. Please login again",
-			})
-		}
+	route.Get("/space/:id/presence", middleware.SpaceMember(db, middleware.SpaceFromParam("id")), func(c *fiber.Ctx) error {
+		space, _ := middleware.CurrentSpace(c)
 
-		spaceIdstring := c.Params("id")
-		spaceId, err := strconv.ParseUint(spaceIdstring, 10, 64)
+		presence, err := redis.SpacePresence(redis.RedisClient, space.ID)
 		if err != nil {
-			log.Warn("Unable to convert string to uint")
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			log.Errorf("Error reading presence: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"status": "error",
-				"error":  "Unable to convert string to uint",
+				"error":  "Failed to fetch presence",
 			})
 		}
 
-		var space models.Space
-		result := db.Joins("JOIN user_spaces ON user_spaces.space_id = spaces.id").
-			Where("spaces.id = ? AND user_spaces.user_id = ?", spaceId, userId).Preload("Members", func(db *gorm.DB) *gorm.DB {
-			return db.Select("users.id", "users.name", "users.nickname")
-		}).
-			First(&space)
-		if result.Error != nil {
-			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"status": "error",
-					"error":  "space not found or user is not a member",
-				})
-			}
-		}
-
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		return c.JSON(fiber.Map{
 			"status": "success",
-			"data":   space,
+			"data":   presence,
+			"count":  len(presence),
 		})
 	})
 
-	route.Patch("/space/:id/join", func(c *fiber.Ctx) error {
-		userId, ok := c.Locals("userId").(uint)
-		if !ok {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"status": "error",
-				"error":  "Unable to get user details. Please login again",
-			})
-		}
+	route.Get("/space/:id", middleware.SpaceMember(db, middleware.SpaceFromParam("id")), func(c *fiber.Ctx) error {
+		space, _ := middleware.CurrentSpace(c)
 
-		// Get the space ID from the URL parameter
-		spaceIdstring := c.Params("id")
-		spaceId, err := strconv.ParseUint(spaceIdstring, 10, 64)
-		if err != nil {
-			log.Warn("Unable to convert string to uint")
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		if err := db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+			return db.Select("users.id", "users.name", "users.nickname")
+		}).First(&space, space.ID).Error; err != nil {
+			log.Warnf("Error fetching space members: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"status": "error",
-				"error":  "Unable to convert string to uint",
+				"error":  "Failed to fetch space members",
 			})
 		}
 
-		var space models.Space
-		if err := db.Preload("Members").First(&space, spaceId).Error; err != nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"status": "error",
-				"error":  "Space not found",
-			})
-		}
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status": "success",
+			"data":   space,
+		})
+	})
 
-		var user models.User
-		if err := db.First(&user, userId).Error; err != nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"status": "error",
-				"error":  "User not found",
-			})
-		}
+	inviteHandlers(route, db)
 
-		for _, member := range space.Members {
-			if member.ID == userId {
-				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-					"status": "error",
-					"error":  "User is already a member of this space",
-				})
-			}
-		}
+	settingsHandlers(route, db)
 
-		if err := db.Model(&space).Association("Members").Append(&user); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"status": "error",
-				"error":  "Failed to add user to the space",
-			})
-		}
+	deletionHandlers(route, db)
 
-		return c.JSON(fiber.Map{
-			"status":  "success",
-			"message": "User successfully added to the space",
-		})
-	})
+	cloneHandlers(route, db)
 
-	route.Delete("/space/:id", func(c *fiber.Ctx) error {
-		userId, ok := c.Locals("userId").(uint)
-		fmt.Println(userId)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"status": "error",
-				"error":  "Unable to get user details. Please login again",
-			})
-		}
+	chatHandlers(route, db)
 
-		spaceIdstring := c.Params("id")
-		spaceId, err := strconv.ParseUint(spaceIdstring, 10, 64)
+	zoneHandlers(route, db)
 
-		if err != nil {
-			log.Warn("Unable to convert string to uint")
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"status": "error",
-				"error":  "Unable to convert string to uint",
-			})
-		}
+	directoryHandlers(route, db)
 
-		var space models.Space
-		if err := db.First(&space, spaceId).Error; err != nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"status": "error",
-				"error":  "Space not found",
-			})
-		}
-		if space.CreatedBy != userId {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"status": "error",
-				"error":  "You are not authorized to delete this space",
-			})
-		}
+	route.Delete("/space/:id", middleware.SpacePermission(db, middleware.SpaceFromParam("id"), models.SpacePermissionDeleteSpace), func(c *fiber.Ctx) error {
+		space, _ := middleware.CurrentSpace(c)
 
-		if err := db.Delete(&space).Error; err != nil {
+		// Every row deleted with the space shares its timestamp, so a restore
+		// brings back exactly what this delete removed
+		deletedAt := time.Now()
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return softDeleteSpace(tx, space.ID, deletedAt)
+		}); err != nil {
+			log.Warnf("Error deleting space: %v", err.Error())
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"status": "error",
 				"error":  "Failed to delete space",
 			})
 		}
 
-		successMessage := fmt.Sprintf("Space #%s %s deleted successfully", spaceIdstring, space.Name)
+		if err := redis.PublishLifecycleEvent(redis.RedisClient, redis.LifecycleEvent{
+			Type:    redis.LifecycleSpaceDeleted,
+			SpaceID: space.ID,
+		}); err != nil {
+			log.Errorf("Failed to publish lifecycle event: %v", err)
+		}
+
+		successMessage := fmt.Sprintf("Space #%d %s deleted successfully", space.ID, space.Name)
 
 		return c.JSON(fiber.Map{
 			"status":  "success",
@@ -212,6 +167,8 @@ func SpaceHandlers(route fiber.Router, db *gorm.DB) {
 
 	})
 
+	memberHandlers(route, db)
+
 	route.Use("/space/ws", func(c *fiber.Ctx) error {
 		if websocket.IsWebSocketUpgrade(c) {
 			c.Locals("allowed", true)
@@ -220,7 +177,7 @@ func SpaceHandlers(route fiber.Router, db *gorm.DB) {
 		return fiber.ErrUpgradeRequired
 	})
 
-	route.Get("/space/ws/:id", websocket.New(func(c *websocket.Conn) {
+	route.Get("/space/ws/:id", middleware.SpaceMember(db, middleware.SpaceFromParam("id")), websocket.New(func(c *websocket.Conn) {
 		spaceServer.HandleWebSocket(c, db)
 	}))
 
@@ -0,0 +1,219 @@
+package space
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/bhav-07/haven/middleware"
+	"github.com/bhav-07/haven/models"
+	"github.com/bhav-07/haven/redis"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+func zoneHandlers(route fiber.Router, db *gorm.DB) {
+	route.Get("/space/:id/zones", middleware.SpaceMember(db, middleware.SpaceFromParam("id")), func(c *fiber.Ctx) error {
+		space, _ := middleware.CurrentSpace(c)
+
+		zones, err := spaceZones(db, space.ID)
+		if err != nil {
+			log.Warnf("Error fetching zones: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to fetch zones",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"status": "success",
+			"data":   zones,
+		})
+	})
+
+	route.Post("/space/:id/zones", middleware.SpacePermission(db, middleware.SpaceFromParam("id"), models.SpacePermissionManageSpace), func(c *fiber.Ctx) error {
+		space, _ := middleware.CurrentSpace(c)
+
+		zone := new(models.SpaceZone)
+		if err := c.BodyParser(zone); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid request body",
+			})
+		}
+
+		zone.Model = gorm.Model{}
+		zone.SpaceID = space.ID
+		if err := validateZone(zone); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  err.Error(),
+			})
+		}
+
+		if err := db.Create(zone).Error; err != nil {
+			log.Warnf("Error creating zone: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to create zone",
+			})
+		}
+
+		publishZones(db, space.ID)
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Zone created successfully",
+			"data":    zone,
+		})
+	})
+
+	route.Patch("/space/:id/zones/:zoneID", middleware.SpacePermission(db, middleware.SpaceFromParam("id"), models.SpacePermissionManageSpace), func(c *fiber.Ctx) error {
+		space, _ := middleware.CurrentSpace(c)
+
+		var zone models.SpaceZone
+		if err := db.Where("id = ? AND space_id = ?", c.Params("zoneID"), space.ID).First(&zone).Error; err != nil {
+			return zoneLookupError(c, err)
+		}
+
+		type UpdateZoneRequest struct {
+			Name   *string  `json:"name"`
+			X      *float64 `json:"x"`
+			Y      *float64 `json:"y"`
+			Width  *float64 `json:"width"`
+			Height *float64 `json:"height"`
+		}
+		req := new(UpdateZoneRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Invalid request body",
+			})
+		}
+
+		var fields []string
+		if req.Name != nil {
+			zone.Name = *req.Name
+			fields = append(fields, "name")
+		}
+		if req.X != nil {
+			zone.X = *req.X
+			fields = append(fields, "x")
+		}
+		if req.Y != nil {
+			zone.Y = *req.Y
+			fields = append(fields, "y")
+		}
+		if req.Width != nil {
+			zone.Width = *req.Width
+			fields = append(fields, "width")
+		}
+		if req.Height != nil {
+			zone.Height = *req.Height
+			fields = append(fields, "height")
+		}
+
+		if len(fields) == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  "No fields to update",
+			})
+		}
+
+		if err := validateZone(&zone); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"error":  err.Error(),
+			})
+		}
+
+		if err := db.Model(&zone).Select(fields).Updates(&zone).Error; err != nil {
+			log.Warnf("Error updating zone: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to update zone",
+			})
+		}
+
+		publishZones(db, space.ID)
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Zone updated successfully",
+			"data":    zone,
+		})
+	})
+
+	route.Delete("/space/:id/zones/:zoneID", middleware.SpacePermission(db, middleware.SpaceFromParam("id"), models.SpacePermissionManageSpace), func(c *fiber.Ctx) error {
+		space, _ := middleware.CurrentSpace(c)
+
+		result := db.Where("id = ? AND space_id = ?", c.Params("zoneID"), space.ID).Delete(&models.SpaceZone{})
+		if result.Error == nil && result.RowsAffected == 0 {
+			result.Error = gorm.ErrRecordNotFound
+		}
+		if result.Error != nil {
+			return zoneLookupError(c, result.Error)
+		}
+
+		publishZones(db, space.ID)
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Zone deleted successfully",
+		})
+	})
+}
+
+func spaceZones(db *gorm.DB, spaceId uint) ([]models.SpaceZone, error) {
+	zones := []models.SpaceZone{}
+	err := db.Where("space_id = ?", spaceId).Order("name").Find(&zones).Error
+	return zones, err
+}
+
+func validateZone(zone *models.SpaceZone) error {
+	zone.Name = strings.TrimSpace(zone.Name)
+	if zone.Name == "" {
+		return errors.New("Zone name is required")
+	}
+	if len(zone.Name) > 100 {
+		return errors.New("Zone name cannot be longer than 100 characters")
+	}
+	if zone.X < 0 || zone.Y < 0 {
+		return errors.New("Zones must lie on the map")
+	}
+	if zone.Width <= 0 || zone.Height <= 0 {
+		return errors.New("Zones need a positive width and height")
+	}
+	return nil
+}
+
+// publishZones sends the current zones to every replica, so connected players
+// are placed in the right zone and clients can redraw them.
+func publishZones(db *gorm.DB, spaceId uint) {
+	zones, err := spaceZones(db, spaceId)
+	if err != nil {
+		log.Errorf("Error fetching zones: %v", err)
+		return
+	}
+
+	if err := redis.PublishSpaceEvent(redis.RedisClient, spaceId, "zones_updated", map[string]interface{}{
+		"zones": zones,
+	}); err != nil {
+		log.Errorf("Failed to publish space event: %v", err)
+	}
+}
+
+func zoneLookupError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Zone not found",
+		})
+	}
+
+	log.Warnf("Error updating zone: %v", err.Error())
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"status": "error",
+		"error":  "Failed to update zone",
+	})
+}
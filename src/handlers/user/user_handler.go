@@ -38,6 +38,18 @@ func UserHandlers(route fiber.Router, db *gorm.DB) {
 			})
 		}
 
+		spaceIds := make([]uint, len(spaces))
+		for i, space := range spaces {
+			spaceIds[i] = space.ID
+		}
+		online, err := redis.PresenceCounts(redis.RedisClient, spaceIds)
+		if err != nil {
+			log.Errorf("Error reading presence: %v", err)
+		}
+		for i := range spaces {
+			spaces[i].Online = online[spaces[i].ID]
+		}
+
 		return c.JSON(fiber.Map{
 			"status": "success",
 			"data":   spaces,
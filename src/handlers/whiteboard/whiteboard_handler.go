@@ -1,6 +1,7 @@
 package whiteboard
 
 import (
+	"github.com/bhav-07/haven/middleware"
 	"github.com/bhav-07/haven/redis"
 	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
@@ -19,5 +20,5 @@ func WhiteboardHandlers(route fiber.Router, db *gorm.DB) {
 		return fiber.ErrUpgradeRequired
 	})
 
-	route.Get("/whiteboard/ws/:spaceId", websocket.New(whiteboardServer.HandleWebSocket))
+	route.Get("/whiteboard/ws/:spaceId", middleware.SpaceMember(db, middleware.SpaceFromParam("spaceId")), websocket.New(whiteboardServer.HandleWebSocket))
 }
@@ -0,0 +1,160 @@
+// Package maps holds the maps spaces can use, with the collision grid the
+// client renders from web/public and the spawn points players start at.
+// Each map is a directory with a map.json of metadata and a collisions.json
+// grid of tile values, which must match the copy shipped to the client.
+package maps
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"math/rand"
+	"path"
+)
+
+// DefaultMap is used for spaces whose map is not known to the server
+const DefaultMap = "officecozy"
+
+//go:embed */map.json */collisions.json
+var files embed.FS
+
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type Map struct {
+	Name         string  `json:"name"`
+	Width        float64 `json:"width"`
+	Height       float64 `json:"height"`
+	TileSize     float64 `json:"tile_size"`
+	BlockedTiles []int   `json:"blocked_tiles"`
+	Spawns       []Point `json:"spawns"`
+	// blocked[row][column] is true for tiles players cannot walk on
+	blocked [][]bool
+}
+
+var loaded = mustLoad()
+
+func mustLoad() map[string]*Map {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		panic(fmt.Sprintf("Error reading maps: %v", err))
+	}
+
+	maps := make(map[string]*Map, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		m, err := load(entry.Name())
+		if err != nil {
+			panic(fmt.Sprintf("Error loading map %s: %v", entry.Name(), err))
+		}
+		maps[m.Name] = m
+	}
+	return maps
+}
+
+func load(name string) (*Map, error) {
+	metadata, err := files.ReadFile(path.Join(name, "map.json"))
+	if err != nil {
+		return nil, err
+	}
+	m := &Map{Name: name}
+	if err := json.Unmarshal(metadata, m); err != nil {
+		return nil, err
+	}
+	if m.TileSize <= 0 || len(m.Spawns) == 0 {
+		return nil, fmt.Errorf("map needs a tile size and at least one spawn point")
+	}
+
+	collisions, err := files.ReadFile(path.Join(name, "collisions.json"))
+	if err != nil {
+		return nil, err
+	}
+	var grid [][]int
+	if err := json.Unmarshal(collisions, &grid); err != nil {
+		return nil, err
+	}
+
+	blockedTiles := make(map[int]bool, len(m.BlockedTiles))
+	for _, tile := range m.BlockedTiles {
+		blockedTiles[tile] = true
+	}
+	m.blocked = make([][]bool, len(grid))
+	for row, tiles := range grid {
+		m.blocked[row] = make([]bool, len(tiles))
+		for column, tile := range tiles {
+			m.blocked[row][column] = blockedTiles[tile]
+		}
+	}
+
+	for _, spawn := range m.Spawns {
+		if m.Blocked(spawn) {
+			return nil, fmt.Errorf("spawn point %v is blocked", spawn)
+		}
+	}
+	return m, nil
+}
+
+// Get returns the named map, or the default map when it is not known.
+func Get(name string) *Map {
+	if m, ok := loaded[name]; ok {
+		return m
+	}
+	return loaded[DefaultMap]
+}
+
+func Exists(name string) bool {
+	_, ok := loaded[name]
+	return ok
+}
+
+// Blocked reports whether players cannot stand at the point. Everything
+// outside the map is blocked, as is any part the collision grid does not cover.
+func (m *Map) Blocked(p Point) bool {
+	if p.X < 0 || p.Y < 0 || p.X >= m.Width || p.Y >= m.Height {
+		return true
+	}
+	row := int(p.Y / m.TileSize)
+	column := int(p.X / m.TileSize)
+	if row >= len(m.blocked) || column >= len(m.blocked[row]) {
+		return true
+	}
+	return m.blocked[row][column]
+}
+
+// PathBlocked reports whether the player cannot get from one point to the
+// other, straight or around a corner since players walk along the axes.
+func (m *Map) PathBlocked(from Point, to Point) bool {
+	if !m.lineBlocked(from, to) {
+		return false
+	}
+	for _, corner := range []Point{{X: to.X, Y: from.Y}, {X: from.X, Y: to.Y}} {
+		if !m.lineBlocked(from, corner) && !m.lineBlocked(corner, to) {
+			return false
+		}
+	}
+	return true
+}
+
+// lineBlocked checks every half tile along the straight line between the points.
+func (m *Map) lineBlocked(from Point, to Point) bool {
+	distance := math.Hypot(to.X-from.X, to.Y-from.Y)
+	steps := int(math.Ceil(distance / (m.TileSize / 2)))
+	for i := 1; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		if m.Blocked(Point{X: from.X + (to.X-from.X)*t, Y: from.Y + (to.Y-from.Y)*t}) {
+			return true
+		}
+	}
+	return m.Blocked(to)
+}
+
+// Spawn picks one of the spawn points of the map.
+func (m *Map) Spawn() Point {
+	return m.Spawns[rand.Intn(len(m.Spawns))]
+}
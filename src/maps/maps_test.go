@@ -0,0 +1,109 @@
+package maps
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// newTestMap builds a map of 10px tiles from rows where '#' is a wall. Rows
+// can be shorter than the map, leaving part of it outside the grid.
+func newTestMap(width, height float64, rows ...string) *Map {
+	m := &Map{Name: "test", Width: width, Height: height, TileSize: 10}
+	m.blocked = make([][]bool, len(rows))
+	for row, tiles := range rows {
+		m.blocked[row] = make([]bool, len(tiles))
+		for column, tile := range tiles {
+			m.blocked[row][column] = tile == '#'
+		}
+	}
+	return m
+}
+
+// A 5x4 tile map whose grid misses the last column of row 1 and all of row 3
+var testMap = newTestMap(50, 40,
+	".....",
+	".##.",
+	".....",
+)
+
+func TestBlocked(t *testing.T) {
+	tests := []struct {
+		name  string
+		point Point
+		want  bool
+	}{
+		{"open tile", Point{X: 5, Y: 5}, false},
+		{"wall", Point{X: 15, Y: 15}, true},
+		{"tile edge", Point{X: 30, Y: 10}, false},
+		{"left of the map", Point{X: -1, Y: 5}, true},
+		{"above the map", Point{X: 5, Y: -1}, true},
+		{"right of the map", Point{X: 50, Y: 5}, true},
+		{"below the map", Point{X: 5, Y: 40}, true},
+		{"missing column", Point{X: 45, Y: 15}, true},
+		{"missing row", Point{X: 5, Y: 35}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testMap.Blocked(tt.point); got != tt.want {
+				t.Errorf("Blocked(%v) = %v, want %v", tt.point, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathBlocked(t *testing.T) {
+	tests := []struct {
+		name string
+		from Point
+		to   Point
+		want bool
+	}{
+		{"standing still", Point{X: 5, Y: 5}, Point{X: 5, Y: 5}, false},
+		{"straight along an open row", Point{X: 5, Y: 5}, Point{X: 45, Y: 5}, false},
+		{"through a wall", Point{X: 5, Y: 15}, Point{X: 35, Y: 15}, true},
+		{"around a corner", Point{X: 5, Y: 5}, Point{X: 35, Y: 25}, false},
+		{"both corners walled", Point{X: 15, Y: 5}, Point{X: 25, Y: 25}, true},
+		{"into a missing row", Point{X: 5, Y: 25}, Point{X: 5, Y: 35}, true},
+		{"off the map", Point{X: 45, Y: 5}, Point{X: 55, Y: 5}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testMap.PathBlocked(tt.from, tt.to); got != tt.want {
+				t.Errorf("PathBlocked(%v, %v) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+// The client renders and walks the copy of the collision grid in web/public,
+// which must stay in sync with the one the server checks moves against.
+func TestCollisionsMatchClient(t *testing.T) {
+	client, err := os.ReadFile("../../web/public/collisions.json")
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Skip("web client is not checked out")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := files.ReadFile(DefaultMap + "/collisions.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var clientGrid, serverGrid [][]int
+	if err := json.Unmarshal(client, &clientGrid); err != nil {
+		t.Fatalf("parsing web/public/collisions.json: %v", err)
+	}
+	if err := json.Unmarshal(server, &serverGrid); err != nil {
+		t.Fatalf("parsing %s/collisions.json: %v", DefaultMap, err)
+	}
+	if !reflect.DeepEqual(clientGrid, serverGrid) {
+		t.Errorf("maps/%s/collisions.json differs from web/public/collisions.json, copy one over the other", DefaultMap)
+	}
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/bhav-07/haven/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+// SpaceResolver finds the space a request is about. Errors returned as
+// *fiber.Error are sent back with their own status code.
+type SpaceResolver func(c *fiber.Ctx, db *gorm.DB) (uint, error)
+
+// SpaceFromParam reads the space ID from a route parameter, e.g. /space/:id.
+func SpaceFromParam(name string) SpaceResolver {
+	return func(c *fiber.Ctx, db *gorm.DB) (uint, error) {
+		return parseSpaceID(c.Params(name))
+	}
+}
+
+// SpaceFromQuery reads the space ID from a query parameter, e.g. ?spaceID=.
+func SpaceFromQuery(name string) SpaceResolver {
+	return func(c *fiber.Ctx, db *gorm.DB) (uint, error) {
+		return parseSpaceID(c.Query(name))
+	}
+}
+
+// SpaceFromRecord looks up the space of the record whose ID is in the given
+// route parameter, answering 404 with notFound when there is none. The model
+// must have a space_id column. Soft deleted records are included so their
+// history stays reachable.
+func SpaceFromRecord(model interface{}, param string, notFound string) SpaceResolver {
+	return func(c *fiber.Ctx, db *gorm.DB) (uint, error) {
+		id, err := strconv.ParseUint(c.Params(param), 10, 64)
+		if err != nil {
+			log.Warn("Unable to convert string to uint")
+			return 0, fiber.NewError(fiber.StatusBadRequest, "Unable to convert string to uint")
+		}
+
+		var spaceIds []uint
+		if err := db.Unscoped().Model(model).Where("id = ?", id).Pluck("space_id", &spaceIds).Error; err != nil {
+			return 0, err
+		}
+		if len(spaceIds) == 0 {
+			return 0, fiber.NewError(fiber.StatusNotFound, notFound)
+		}
+
+		return spaceIds[0], nil
+	}
+}
+
+// SpaceMember only lets members of the resolved space through. The space is
+// stored in c.Locals("space") and the caller's role in c.Locals("spaceRole").
+func SpaceMember(db *gorm.DB, resolve SpaceResolver) fiber.Handler {
+	return SpacePermission(db, resolve, models.SpacePermissionView)
+}
+
+// SpacePermission is SpaceMember restricted to roles holding the permission.
+func SpacePermission(db *gorm.DB, resolve SpaceResolver, permission models.SpacePermission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userId, ok := c.Locals("userId").(uint)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Unable to get user details. Please login again",
+			})
+		}
+
+		spaceId, err := resolve(c, db)
+		if err != nil {
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				return c.Status(fiberErr.Code).JSON(fiber.Map{
+					"status": "error",
+					"error":  fiberErr.Message,
+				})
+			}
+			log.Warnf("Error resolving space: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to load space",
+			})
+		}
+
+		// Non members get the same answer as for a missing space, so space IDs cannot be probed
+		var membership models.UserSpace
+		var space models.Space
+		err = db.Where("space_id = ? AND user_id = ?", spaceId, userId).First(&membership).Error
+		if err == nil {
+			err = db.First(&space, spaceId).Error
+		}
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"status": "error",
+					"error":  "space not found or user is not a member",
+				})
+			}
+			log.Warnf("Error checking space membership: %v", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Failed to load space",
+			})
+		}
+
+		if !membership.Role.Can(permission) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status": "error",
+				"error":  "Your role in this space does not allow this",
+			})
+		}
+
+		if space.ArchivedAt != nil && !permission.AllowedWhenArchived() {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"status": "error",
+				"error":  "This space is archived and read-only",
+			})
+		}
+
+		c.Locals("space", space)
+		c.Locals("spaceRole", membership.Role)
+
+		return c.Next()
+	}
+}
+
+// CurrentSpace returns the space stored by SpaceMember or SpacePermission.
+func CurrentSpace(c *fiber.Ctx) (models.Space, bool) {
+	space, ok := c.Locals("space").(models.Space)
+	return space, ok
+}
+
+// CurrentSpaceRole returns the caller's role in the current space.
+func CurrentSpaceRole(c *fiber.Ctx) models.SpaceRole {
+	role, _ := c.Locals("spaceRole").(models.SpaceRole)
+	return role
+}
+
+func parseSpaceID(value string) (uint, error) {
+	spaceId, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		log.Warn("Unable to convert string to uint")
+		return 0, fiber.NewError(fiber.StatusBadRequest, "Unable to convert string to uint")
+	}
+	return uint(spaceId), nil
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -24,6 +25,154 @@ func (s UserStatus) IsValid() bool {
 	return false
 }
 
+type SpaceRole string
+
+const (
+	SpaceRoleOwner  SpaceRole = "owner"
+	SpaceRoleAdmin  SpaceRole = "admin"
+	SpaceRoleMember SpaceRole = "member"
+	SpaceRoleGuest  SpaceRole = "guest"
+)
+
+func (r SpaceRole) IsValid() bool {
+	switch r {
+	case SpaceRoleOwner, SpaceRoleAdmin,
+		SpaceRoleMember, SpaceRoleGuest:
+		return true
+	}
+	return false
+}
+
+// Outranks reports whether r sits strictly above other, owner being the highest.
+func (r SpaceRole) Outranks(other SpaceRole) bool {
+	return spaceRoleRanks[r] > spaceRoleRanks[other]
+}
+
+var spaceRoleRanks = map[SpaceRole]int{
+	SpaceRoleGuest:  1,
+	SpaceRoleMember: 2,
+	SpaceRoleAdmin:  3,
+	SpaceRoleOwner:  4,
+}
+
+type SpacePermission string
+
+const (
+	SpacePermissionView              SpacePermission = "view"
+	SpacePermissionEditWhiteboard    SpacePermission = "edit_whiteboard"
+	SpacePermissionEditTasks         SpacePermission = "edit_tasks"
+	SpacePermissionDeleteTasks       SpacePermission = "delete_tasks"
+	SpacePermissionManageBoard       SpacePermission = "manage_board"
+	SpacePermissionManageMembers     SpacePermission = "manage_members"
+	SpacePermissionManageSpace       SpacePermission = "manage_space"
+	SpacePermissionTransferOwnership SpacePermission = "transfer_ownership"
+	SpacePermissionDeleteSpace       SpacePermission = "delete_space"
+	SpacePermissionModerateChat      SpacePermission = "moderate_chat"
+)
+
+// spaceRolePermissions is the permission matrix of space roles.
+// Members can still delete the tasks they created themselves, and edit or
+// delete their own chat messages.
+var spaceRolePermissions = map[SpaceRole][]SpacePermission{
+	SpaceRoleOwner: {
+		SpacePermissionView, SpacePermissionEditWhiteboard, SpacePermissionEditTasks,
+		SpacePermissionDeleteTasks, SpacePermissionManageBoard, SpacePermissionManageMembers,
+		SpacePermissionManageSpace, SpacePermissionTransferOwnership, SpacePermissionDeleteSpace,
+		SpacePermissionModerateChat,
+	},
+	SpaceRoleAdmin: {
+		SpacePermissionView, SpacePermissionEditWhiteboard, SpacePermissionEditTasks,
+		SpacePermissionDeleteTasks, SpacePermissionManageBoard, SpacePermissionManageMembers,
+		SpacePermissionManageSpace, SpacePermissionModerateChat,
+	},
+	SpaceRoleMember: {
+		SpacePermissionView, SpacePermissionEditWhiteboard, SpacePermissionEditTasks,
+	},
+	SpaceRoleGuest: {
+		SpacePermissionView,
+	},
+}
+
+func (r SpaceRole) Can(permission SpacePermission) bool {
+	for _, p := range spaceRolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedWhenArchived reports whether the permission can still be used in an
+// archived space. Archived spaces are read-only apart from their administration.
+func (p SpacePermission) AllowedWhenArchived() bool {
+	switch p {
+	case SpacePermissionView, SpacePermissionManageMembers, SpacePermissionManageSpace,
+		SpacePermissionTransferOwnership, SpacePermissionDeleteSpace:
+		return true
+	}
+	return false
+}
+
+type SpaceVisibility string
+
+// Private spaces can only be joined with an invite. Link-only spaces can also
+// be joined by anyone who has their share link, a single reusable token kept
+// on the space, organization spaces by users sharing the owner's email domain,
+// and public spaces by anyone. Organization and public spaces are also listed
+// in the directory.
+const (
+	SpaceVisibilityPrivate      SpaceVisibility = "private"
+	SpaceVisibilityLinkOnly     SpaceVisibility = "link_only"
+	SpaceVisibilityOrganization SpaceVisibility = "organization"
+	SpaceVisibilityPublic       SpaceVisibility = "public"
+)
+
+func (v SpaceVisibility) IsValid() bool {
+	switch v {
+	case SpaceVisibilityPrivate, SpaceVisibilityLinkOnly,
+		SpaceVisibilityOrganization, SpaceVisibilityPublic:
+		return true
+	}
+	return false
+}
+
+type TaskPriority string
+
+const (
+	TaskPriorityHigh   TaskPriority = "HIGH"
+	TaskPriorityMedium TaskPriority = "MEDIUM"
+	TaskPriorityLow    TaskPriority = "LOW"
+)
+
+func (p TaskPriority) IsValid() bool {
+	switch p {
+	case TaskPriorityHigh, TaskPriorityMedium, TaskPriorityLow:
+		return true
+	}
+	return false
+}
+
+// TaskStatus is the name of a column in the space's Kanban workflow.
+// The constants below are the columns every new space starts with.
+type TaskStatus string
+
+const (
+	TaskStatusTodo       TaskStatus = "TODO"
+	TaskStatusInProgress TaskStatus = "IN_PROGRESS"
+	TaskStatusInReview   TaskStatus = "IN_REVIEW"
+	TaskStatusDone       TaskStatus = "DONE"
+)
+
+type TaskAction string
+
+const (
+	TaskActionCreated       TaskAction = "created"
+	TaskActionUpdated       TaskAction = "updated"
+	TaskActionStatusChanged TaskAction = "status_changed"
+	TaskActionMoved         TaskAction = "moved"
+	TaskActionDeleted       TaskAction = "deleted"
+)
+
 type User struct {
 	gorm.Model
 	Email     string     `json:"email" gorm:"type:text;unique;not null"`
@@ -40,7 +189,36 @@ type Space struct {
 	CreatedBy       uint            `json:"created_by" gorm:"not null"`
 	Members         []User          `gorm:"many2many:user_spaces;"`
 	Map             string          `json:"map" gorm:"default:officecozy"`
+	Description     string          `json:"description" gorm:"type:text"`
+	Visibility      SpaceVisibility `json:"visibility" gorm:"type:varchar(20);not null;default:'private'"`
+	ShareTokenHash  *string         `json:"-" gorm:"type:text;uniqueIndex"`
+	ArchivedAt      *time.Time      `json:"archived_at"`
+	IsTemplate      bool            `json:"is_template" gorm:"not null;default:false"`
 	SpaceWhiteboard SpaceWhiteboard `json:"whiteboard" gorm:"foreignKey:SpaceID"`
+	Online          int64           `json:"online" gorm:"-"`
+}
+
+// UserSpace is the user_spaces join table behind Space.Members and User.Spaces.
+type UserSpace struct {
+	UserID    uint           `json:"user_id" gorm:"primaryKey"`
+	SpaceID   uint           `json:"space_id" gorm:"primaryKey"`
+	Role      SpaceRole      `json:"role" gorm:"type:varchar(20);not null;default:'member'"`
+	CreatedAt time.Time      `json:"joined_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// SpaceInvite lets whoever holds its token join the space with Role. Only a
+// hash of the token is stored, the token itself is shown once on creation.
+type SpaceInvite struct {
+	gorm.Model
+	SpaceID   uint       `json:"space_id" gorm:"not null;index"`
+	CreatedBy uint       `json:"created_by" gorm:"not null"`
+	TokenHash string     `json:"-" gorm:"type:text;not null;uniqueIndex"`
+	Role      SpaceRole  `json:"role" gorm:"type:varchar(20);not null;default:'member'"`
+	Email     string     `json:"email" gorm:"type:text"`
+	MaxUses   int        `json:"max_uses" gorm:"not null;default:0"`
+	Uses      int        `json:"uses" gorm:"not null;default:0"`
+	ExpiresAt *time.Time `json:"expires_at"`
 }
 
 type SpaceWhiteboard struct {
@@ -50,12 +228,123 @@ type SpaceWhiteboard struct {
 	AppState json.RawMessage `json:"app_state" gorm:"type:jsonb;default:'{}'::jsonb"`
 }
 
+type SpaceChatMessage struct {
+	gorm.Model
+	SpaceID  uint       `json:"space_id" gorm:"not null;index"`
+	AuthorID uint       `json:"author_id" gorm:"not null"`
+	Author   *User      `json:"author,omitempty" gorm:"foreignKey:AuthorID"`
+	Content  string     `json:"content" gorm:"type:text;not null"`
+	EditedAt *time.Time `json:"edited_at"`
+}
+
+// SpaceZone is a named rectangle of the map, like a meeting room. Chat sent
+// to a zone only reaches the players inside it.
+type SpaceZone struct {
+	gorm.Model
+	SpaceID uint    `json:"space_id" gorm:"not null;index"`
+	Name    string  `json:"name" gorm:"type:varchar(100);not null"`
+	X       float64 `json:"x" gorm:"not null"`
+	Y       float64 `json:"y" gorm:"not null"`
+	Width   float64 `json:"width" gorm:"not null"`
+	Height  float64 `json:"height" gorm:"not null"`
+}
+
+func (z SpaceZone) Contains(x, y float64) bool {
+	return x >= z.X && x < z.X+z.Width && y >= z.Y && y < z.Y+z.Height
+}
+
+// DirectMessage is a private message between two members of a space. ReadAt
+// stays empty until the recipient acknowledges it.
+type DirectMessage struct {
+	gorm.Model
+	SpaceID     uint       `json:"space_id" gorm:"not null;index"`
+	SenderID    uint       `json:"sender_id" gorm:"not null"`
+	Sender      *User      `json:"sender,omitempty" gorm:"foreignKey:SenderID"`
+	RecipientID uint       `json:"recipient_id" gorm:"not null;index"`
+	Content     string     `json:"content" gorm:"type:text;not null"`
+	ReadAt      *time.Time `json:"read_at"`
+}
+
+// SpaceChatReaction is one emoji a user reacted to a chat message with.
+type SpaceChatReaction struct {
+	MessageID uint      `json:"message_id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"primaryKey"`
+	Emoji     string    `json:"emoji" gorm:"primaryKey;type:varchar(32)"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type KanbanTasks struct {
 	gorm.Model
-	SpaceID     uint   `json:"space_id" gorm:"not null"`
-	Title       string `json:"title" gorm:"type:text;not null"`
-	Description string `json:"description" gorm:"type:text"`
-	Status      string `json:"status" gorm:"type:text;not null;default:'TODO'"`
-	Priority    string `json:"priority" gorm:"type:text;not null;default:'MEDIUM'"`
-	DueDate     string `json:"due_date" gorm:"type:text;not null"`
+	SpaceID     uint          `json:"space_id" gorm:"not null"`
+	Title       string        `json:"title" gorm:"type:text;not null"`
+	Description string        `json:"description" gorm:"type:text"`
+	Status      TaskStatus    `json:"status" gorm:"type:text;not null;default:'TODO'"`
+	Priority    TaskPriority  `json:"priority" gorm:"type:text;not null;default:'MEDIUM'"`
+	DueDate     string        `json:"due_date" gorm:"type:text;not null"`
+	Rank        string        `json:"rank" gorm:"type:text;not null;default:''"`
+	CreatedBy   uint          `json:"created_by" gorm:"index"`
+	Creator     *User         `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
+	AssigneeID  *uint         `json:"assignee_id" gorm:"index"`
+	Assignee    *User         `json:"assignee,omitempty" gorm:"foreignKey:AssigneeID"`
+	Watchers    []User        `json:"watchers,omitempty" gorm:"many2many:kanban_task_watchers;"`
+	ParentID    *uint         `json:"parent_id" gorm:"index"`
+	Labels      []KanbanLabel `json:"labels,omitempty" gorm:"many2many:kanban_task_labels;"`
+	Progress    *TaskProgress `json:"progress,omitempty" gorm:"-"`
+}
+
+// TaskProgress is computed when listing tasks, it is not stored.
+type TaskProgress struct {
+	ChecklistDone  int64 `json:"checklist_done"`
+	ChecklistTotal int64 `json:"checklist_total"`
+	SubtasksDone   int64 `json:"subtasks_done"`
+	SubtasksTotal  int64 `json:"subtasks_total"`
+}
+
+type KanbanColumn struct {
+	gorm.Model
+	SpaceID  uint       `json:"space_id" gorm:"not null;index"`
+	Name     TaskStatus `json:"name" gorm:"type:text;not null"`
+	Color    string     `json:"color" gorm:"type:text;not null;default:'#a3a3a3'"`
+	WIPLimit int        `json:"wip_limit" gorm:"not null;default:0"`
+	IsDone   bool       `json:"is_done" gorm:"not null;default:false"`
+	Position int        `json:"position" gorm:"not null;default:0"`
+}
+
+type KanbanComment struct {
+	gorm.Model
+	TaskID   uint       `json:"task_id" gorm:"not null;index"`
+	AuthorID uint       `json:"author_id" gorm:"not null"`
+	Author   *User      `json:"author,omitempty" gorm:"foreignKey:AuthorID"`
+	Body     string     `json:"body" gorm:"type:text;not null"`
+	EditedAt *time.Time `json:"edited_at"`
+	Mentions []User     `json:"mentions,omitempty" gorm:"many2many:kanban_comment_mentions;"`
+}
+
+// KanbanActivity is an append-only audit record, so it has no UpdatedAt or DeletedAt.
+type KanbanActivity struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time  `json:"created_at"`
+	SpaceID   uint       `json:"space_id" gorm:"not null;index"`
+	TaskID    uint       `json:"task_id" gorm:"not null;index"`
+	ActorID   uint       `json:"actor_id" gorm:"not null"`
+	Actor     *User      `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+	Action    TaskAction `json:"action" gorm:"type:text;not null"`
+	Field     string     `json:"field,omitempty" gorm:"type:text"`
+	OldValue  string     `json:"old_value,omitempty" gorm:"type:text"`
+	NewValue  string     `json:"new_value,omitempty" gorm:"type:text"`
+}
+
+type KanbanChecklistItem struct {
+	gorm.Model
+	TaskID   uint   `json:"task_id" gorm:"not null;index"`
+	Text     string `json:"text" gorm:"type:text;not null"`
+	Done     bool   `json:"done" gorm:"not null;default:false"`
+	Position int    `json:"position" gorm:"not null;default:0"`
+}
+
+type KanbanLabel struct {
+	gorm.Model
+	SpaceID uint   `json:"space_id" gorm:"not null;index"`
+	Name    string `json:"name" gorm:"type:text;not null"`
+	Color   string `json:"color" gorm:"type:text;not null;default:'#a3a3a3'"`
 }
@@ -0,0 +1,88 @@
+package models
+
+import "testing"
+
+func TestSpaceRoleCan(t *testing.T) {
+	roles := []SpaceRole{SpaceRoleOwner, SpaceRoleAdmin, SpaceRoleMember, SpaceRoleGuest}
+
+	tests := []struct {
+		permission SpacePermission
+		// allowed lists the roles holding the permission
+		allowed []SpaceRole
+	}{
+		{SpacePermissionView, roles},
+		{SpacePermissionEditWhiteboard, []SpaceRole{SpaceRoleOwner, SpaceRoleAdmin, SpaceRoleMember}},
+		{SpacePermissionEditTasks, []SpaceRole{SpaceRoleOwner, SpaceRoleAdmin, SpaceRoleMember}},
+		{SpacePermissionDeleteTasks, []SpaceRole{SpaceRoleOwner, SpaceRoleAdmin}},
+		{SpacePermissionManageBoard, []SpaceRole{SpaceRoleOwner, SpaceRoleAdmin}},
+		{SpacePermissionManageMembers, []SpaceRole{SpaceRoleOwner, SpaceRoleAdmin}},
+		{SpacePermissionManageSpace, []SpaceRole{SpaceRoleOwner, SpaceRoleAdmin}},
+		{SpacePermissionModerateChat, []SpaceRole{SpaceRoleOwner, SpaceRoleAdmin}},
+		{SpacePermissionTransferOwnership, []SpaceRole{SpaceRoleOwner}},
+		{SpacePermissionDeleteSpace, []SpaceRole{SpaceRoleOwner}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.permission), func(t *testing.T) {
+			allowed := make(map[SpaceRole]bool, len(tt.allowed))
+			for _, role := range tt.allowed {
+				allowed[role] = true
+			}
+			for _, role := range roles {
+				if got := role.Can(tt.permission); got != allowed[role] {
+					t.Errorf("%s.Can(%s) = %v, want %v", role, tt.permission, got, allowed[role])
+				}
+			}
+		})
+	}
+
+	t.Run("unknown role", func(t *testing.T) {
+		if SpaceRole("superuser").Can(SpacePermissionView) {
+			t.Error("an unknown role should not hold any permission")
+		}
+	})
+}
+
+func TestSpaceRoleOutranks(t *testing.T) {
+	tests := []struct {
+		role  SpaceRole
+		other SpaceRole
+		want  bool
+	}{
+		{SpaceRoleOwner, SpaceRoleAdmin, true},
+		{SpaceRoleOwner, SpaceRoleGuest, true},
+		{SpaceRoleAdmin, SpaceRoleMember, true},
+		{SpaceRoleMember, SpaceRoleGuest, true},
+		{SpaceRoleAdmin, SpaceRoleAdmin, false},
+		{SpaceRoleAdmin, SpaceRoleOwner, false},
+		{SpaceRoleGuest, SpaceRoleMember, false},
+		{SpaceRole("superuser"), SpaceRoleGuest, false},
+		{SpaceRoleGuest, SpaceRole("superuser"), true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.role.Outranks(tt.other); got != tt.want {
+			t.Errorf("%s.Outranks(%s) = %v, want %v", tt.role, tt.other, got, tt.want)
+		}
+	}
+}
+
+func TestSpaceRoleIsValid(t *testing.T) {
+	tests := []struct {
+		role SpaceRole
+		want bool
+	}{
+		{SpaceRoleOwner, true},
+		{SpaceRoleAdmin, true},
+		{SpaceRoleMember, true},
+		{SpaceRoleGuest, true},
+		{SpaceRole(""), false},
+		{SpaceRole("Owner"), false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.role.IsValid(); got != tt.want {
+			t.Errorf("SpaceRole(%q).IsValid() = %v, want %v", tt.role, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package redis
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bhav-07/haven/models"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// chatHistoryLimit is how many messages a player gets when joining a space
+	chatHistoryLimit = 50
+	maxChatLength    = 2000
+	// maxEmojiLength allows for emoji built from several code points
+	maxEmojiLength = 8
+)
+
+type ChatMessage struct {
+	// Proximity and zone chat is not stored, so those messages have no ID
+	ID        uint           `json:"id,omitempty"`
+	Time      time.Time      `json:"time"`
+	Content   string         `json:"content"`
+	AuthorID  uint           `json:"author_id"`
+	Author    string         `json:"author"`
+	EditedAt  *time.Time     `json:"edited_at,omitempty"`
+	Reactions []ChatReaction `json:"reactions,omitempty"`
+	Mode      string         `json:"mode,omitempty"`
+	ZoneID    uint           `json:"zone_id,omitempty"`
+	Position  *Position      `json:"position,omitempty"`
+}
+
+type ChatReaction struct {
+	Emoji   string `json:"emoji"`
+	UserIDs []uint `json:"user_ids"`
+}
+
+// ChatHistory returns up to limit messages of the space sent before the given
+// message ID, oldest first. A zero before starts from the latest message.
+func ChatHistory(db *gorm.DB, spaceID uint, before uint, limit int) ([]ChatMessage, error) {
+	query := db.Model(&models.SpaceChatMessage{}).
+		Select(`space_chat_messages.id, space_chat_messages.created_at AS time, space_chat_messages.content,
+			space_chat_messages.author_id, COALESCE(users.nickname, '') AS author, space_chat_messages.edited_at`).
+		Joins("LEFT JOIN users ON users.id = space_chat_messages.author_id").
+		Where("space_chat_messages.space_id = ?", spaceID)
+	if before > 0 {
+		query = query.Where("space_chat_messages.id < ?", before)
+	}
+
+	messages := []ChatMessage{}
+	if err := query.Order("space_chat_messages.id DESC").Limit(limit).Scan(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	if err := loadChatReactions(db, messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// loadChatReactions groups the reactions of the messages by emoji, in the
+// order each emoji was first used.
+func loadChatReactions(db *gorm.DB, messages []ChatMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	indexes := make(map[uint]int, len(messages))
+	messageIds := make([]uint, len(messages))
+	for i, message := range messages {
+		indexes[message.ID] = i
+		messageIds[i] = message.ID
+	}
+
+	var reactions []models.SpaceChatReaction
+	if err := db.Where("message_id IN ?", messageIds).Order("created_at, user_id").Find(&reactions).Error; err != nil {
+		return err
+	}
+
+	for _, reaction := range reactions {
+		message := &messages[indexes[reaction.MessageID]]
+		found := false
+		for i := range message.Reactions {
+			if message.Reactions[i].Emoji == reaction.Emoji {
+				message.Reactions[i].UserIDs = append(message.Reactions[i].UserIDs, reaction.UserID)
+				found = true
+				break
+			}
+		}
+		if !found {
+			message.Reactions = append(message.Reactions, ChatReaction{
+				Emoji:   reaction.Emoji,
+				UserIDs: []uint{reaction.UserID},
+			})
+		}
+	}
+	return nil
+}
+
+// sendChatMessage stores the message and fans it out to the space on every
+// replica. Proximity and zone chat is only relayed to the players in reach.
+func (gs *SpaceServer) sendChatMessage(player *Player, content string, mode string) error {
+	if gs.spaceArchived(player.SpaceID) {
+		return errSpaceArchived
+	}
+	content = strings.TrimSpace(content)
+	if content == "" || len(content) > maxChatLength {
+		return nil
+	}
+
+	spaceID, err := strconv.ParseUint(player.SpaceID, 10, 64)
+	if err != nil {
+		return err
+	}
+	authorID, err := strconv.ParseUint(player.ID, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	switch mode {
+	case "", ChatModeSpace:
+	case ChatModeProximity, ChatModeZone:
+		return gs.sendScopedChatMessage(player, uint(authorID), content, mode)
+	default:
+		return errChatMode
+	}
+
+	record := models.SpaceChatMessage{
+		SpaceID:  uint(spaceID),
+		AuthorID: uint(authorID),
+		Content:  content,
+	}
+	if err := gs.db.Create(&record).Error; err != nil {
+		return err
+	}
+
+	gs.publishToRedis("game:chat", "chat_message", map[string]interface{}{
+		"space_id":  player.SpaceID,
+		"id":        record.ID,
+		"time":      record.CreatedAt,
+		"content":   record.Content,
+		"author_id": record.AuthorID,
+		"author":    player.Nickname,
+	})
+	return nil
+}
+
+var (
+	errChatForbidden = errors.New("you cannot change this message")
+	errChatMode      = errors.New("unknown chat mode")
+	errChatNoZone    = errors.New("player is not in a zone")
+	errSpaceArchived = errors.New("space is archived")
+)
+
+// playerChatMessage loads a message of the player's space, gorm.ErrRecordNotFound
+// if it does not exist or was deleted.
+func (gs *SpaceServer) playerChatMessage(player *Player, messageID uint) (models.SpaceChatMessage, error) {
+	var message models.SpaceChatMessage
+	err := gs.db.Where("id = ? AND space_id = ?", messageID, player.SpaceID).First(&message).Error
+	return message, err
+}
+
+// editChatMessage lets authors change the content of their own messages.
+func (gs *SpaceServer) editChatMessage(player *Player, messageID uint, content string) error {
+	if gs.spaceArchived(player.SpaceID) {
+		return errSpaceArchived
+	}
+	content = strings.TrimSpace(content)
+	if content == "" || len(content) > maxChatLength {
+		return nil
+	}
+
+	message, err := gs.playerChatMessage(player, messageID)
+	if err != nil {
+		return err
+	}
+	if strconv.FormatUint(uint64(message.AuthorID), 10) != player.ID {
+		return errChatForbidden
+	}
+
+	editedAt := time.Now()
+	if err := gs.db.Model(&message).Updates(map[string]interface{}{
+		"content":   content,
+		"edited_at": editedAt,
+	}).Error; err != nil {
+		return err
+	}
+
+	gs.publishToRedis("game:chat", "chat_message_edited", map[string]interface{}{
+		"space_id":  player.SpaceID,
+		"id":        message.ID,
+		"content":   content,
+		"edited_at": editedAt,
+	})
+	return nil
+}
+
+// deleteChatMessage lets authors delete their own messages, and admins any message.
+func (gs *SpaceServer) deleteChatMessage(player *Player, messageID uint) error {
+	if gs.spaceArchived(player.SpaceID) {
+		return errSpaceArchived
+	}
+	message, err := gs.playerChatMessage(player, messageID)
+	if err != nil {
+		return err
+	}
+	if strconv.FormatUint(uint64(message.AuthorID), 10) != player.ID && !player.Role.Can(models.SpacePermissionModerateChat) {
+		return errChatForbidden
+	}
+
+	err = gs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("message_id = ?", message.ID).Delete(&models.SpaceChatReaction{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&message).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	gs.publishToRedis("game:chat", "chat_message_deleted", map[string]interface{}{
+		"space_id": player.SpaceID,
+		"id":       message.ID,
+	})
+	return nil
+}
+
+// reactToChatMessage adds or removes the player's reaction with the emoji.
+func (gs *SpaceServer) reactToChatMessage(player *Player, messageID uint, emoji string, add bool) error {
+	if gs.spaceArchived(player.SpaceID) {
+		return errSpaceArchived
+	}
+	emoji = strings.TrimSpace(emoji)
+	if emoji == "" || utf8.RuneCountInString(emoji) > maxEmojiLength {
+		return nil
+	}
+
+	userID, err := strconv.ParseUint(player.ID, 10, 64)
+	if err != nil {
+		return err
+	}
+	message, err := gs.playerChatMessage(player, messageID)
+	if err != nil {
+		return err
+	}
+
+	reaction := models.SpaceChatReaction{
+		MessageID: message.ID,
+		UserID:    uint(userID),
+		Emoji:     emoji,
+	}
+
+	var result *gorm.DB
+	messageType := "chat_reaction_added"
+	if add {
+		result = gs.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction)
+	} else {
+		messageType = "chat_reaction_removed"
+		result = gs.db.Where(&reaction).Delete(&models.SpaceChatReaction{})
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Nothing changed, the reaction was already there or already gone
+		return nil
+	}
+
+	gs.publishToRedis("game:chat", messageType, map[string]interface{}{
+		"space_id":   player.SpaceID,
+		"message_id": message.ID,
+		"user_id":    reaction.UserID,
+		"emoji":      emoji,
+	})
+	return nil
+}
+
+// sendChatError tells the player why a change to a chat message was refused.
+func sendChatError(player *Player, messageID uint, err error) {
+	reason := "Failed to update the message"
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		reason = "Message not found"
+	case errors.Is(err, errChatForbidden):
+		reason = "You cannot change this message"
+	case errors.Is(err, errChatMode):
+		reason = "Unknown chat mode"
+	case errors.Is(err, errChatNoZone):
+		reason = "You are not in a zone"
+	case errors.Is(err, errSpaceArchived):
+		reason = "This space is archived"
+	default:
+		log.Errorf("Error updating chat message %d: %v", messageID, err)
+	}
+
+	if err := player.send(Message{
+		Type: "chat_error",
+		Content: map[string]interface{}{
+			"message_id": messageID,
+			"error":      reason,
+		},
+		Time: time.Now(),
+	}); err != nil {
+		log.Errorf("Error sending chat error to player %s: %v", player.ID, err)
+	}
+}
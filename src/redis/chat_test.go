@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestArchivedSpaceChat(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(gs *SpaceServer, player *Player) error
+	}{
+		{"send", func(gs *SpaceServer, player *Player) error {
+			return gs.sendChatMessage(player, "hello", "")
+		}},
+		{"edit", func(gs *SpaceServer, player *Player) error {
+			return gs.editChatMessage(player, 1, "hello")
+		}},
+		{"delete", func(gs *SpaceServer, player *Player) error {
+			return gs.deleteChatMessage(player, 1)
+		}},
+		{"react", func(gs *SpaceServer, player *Player) error {
+			return gs.reactToChatMessage(player, 1, "👍", true)
+		}},
+		{"direct message", func(gs *SpaceServer, player *Player) error {
+			return gs.sendDirectMessage(player, 2, "hello")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player := &Player{ID: "1", SpaceID: "7"}
+			// No database is set, anything past the archived check would panic
+			gs := &SpaceServer{
+				spaces:   map[string]map[string]*Player{"7": {"1": player}},
+				archived: map[string]bool{"7": true},
+			}
+
+			if err := tt.change(gs, player); !errors.Is(err, errSpaceArchived) {
+				t.Errorf("got %v, want errSpaceArchived", err)
+			}
+		})
+	}
+}
+
+func TestLifecycleArchivedFlag(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		spaceID string
+		initial bool
+		want    bool
+	}{
+		{"archived", `{"type":"space_archived","space_id":7}`, "7", false, true},
+		{"unarchived", `{"type":"space_unarchived","space_id":7}`, "7", true, false},
+		{"other space", `{"type":"space_archived","space_id":8}`, "7", false, false},
+		{"unrelated event", `{"type":"role_changed","space_id":7,"user_id":2,"role":"guest"}`, "7", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := &SpaceServer{
+				spaces:   map[string]map[string]*Player{tt.spaceID: {}},
+				archived: map[string]bool{tt.spaceID: tt.initial},
+			}
+
+			gs.handleLifecycleEvent(tt.payload)
+
+			if got := gs.spaceArchived(tt.spaceID); got != tt.want {
+				t.Errorf("spaceArchived(%q) = %v, want %v", tt.spaceID, got, tt.want)
+			}
+			if _, tracked := gs.archived["8"]; tracked && tt.spaceID != "8" {
+				t.Errorf("flag recorded for a space without players here")
+			}
+		})
+	}
+}
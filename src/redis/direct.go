@@ -0,0 +1,180 @@
+package redis
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bhav-07/haven/models"
+	"github.com/bhav-07/haven/utils"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// Direct messages go through their own channel, every replica delivers them
+// to the sender and recipient connections it holds.
+const directChannel = "game:direct"
+
+// unreadDirectLimit caps how many unread messages are delivered on connect
+const unreadDirectLimit = 100
+
+var errDirectRecipient = errors.New("recipient is not a member of this space")
+
+type DirectMessage struct {
+	ID          uint      `json:"id"`
+	Time        time.Time `json:"time"`
+	SpaceID     uint      `json:"space_id"`
+	SenderID    uint      `json:"sender_id"`
+	Sender      string    `json:"sender"`
+	RecipientID uint      `json:"recipient_id"`
+	Content     string    `json:"content"`
+}
+
+// sendDirectMessage stores a message to another member of the player's space
+// and routes it to the recipient wherever they are connected.
+func (gs *SpaceServer) sendDirectMessage(player *Player, recipientID uint, content string) error {
+	if gs.spaceArchived(player.SpaceID) {
+		return errSpaceArchived
+	}
+	content = strings.TrimSpace(content)
+	if content == "" || len(content) > maxChatLength {
+		return nil
+	}
+
+	spaceID, err := strconv.ParseUint(player.SpaceID, 10, 64)
+	if err != nil {
+		return err
+	}
+	senderID, err := strconv.ParseUint(player.ID, 10, 64)
+	if err != nil {
+		return err
+	}
+	if recipientID == 0 || recipientID == uint(senderID) {
+		return errDirectRecipient
+	}
+
+	isMember, err := utils.IsSpaceMember(uint(spaceID), recipientID, gs.db)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return errDirectRecipient
+	}
+
+	record := models.DirectMessage{
+		SpaceID:     uint(spaceID),
+		SenderID:    uint(senderID),
+		RecipientID: recipientID,
+		Content:     content,
+	}
+	if err := gs.db.Create(&record).Error; err != nil {
+		return err
+	}
+
+	gs.publishToRedis(directChannel, "direct_message", DirectMessage{
+		ID:          record.ID,
+		Time:        record.CreatedAt,
+		SpaceID:     record.SpaceID,
+		SenderID:    record.SenderID,
+		Sender:      player.Nickname,
+		RecipientID: record.RecipientID,
+		Content:     record.Content,
+	})
+	return nil
+}
+
+// handleDirectMessage delivers a message to the connections of its sender and
+// of its recipient, unless the recipient is on do not disturb. Those messages
+// stay unread and are delivered later.
+func (gs *SpaceServer) handleDirectMessage(message Message) {
+	payload, err := json.Marshal(message.Content)
+	if err != nil {
+		return
+	}
+	var direct DirectMessage
+	if err := json.Unmarshal(payload, &direct); err != nil {
+		log.Errorf("Error parsing direct message: %v", err)
+		return
+	}
+
+	spaceID := strconv.FormatUint(uint64(direct.SpaceID), 10)
+	senderID := strconv.FormatUint(uint64(direct.SenderID), 10)
+	recipientID := strconv.FormatUint(uint64(direct.RecipientID), 10)
+
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	// Only connections to the space the message was sent in receive it
+	for _, player := range gs.spaces[spaceID] {
+		if player.ID != senderID && (player.ID != recipientID || player.Status == models.UserStatusDND) {
+			continue
+		}
+		if err := player.send(message); err != nil {
+			log.Errorf("Error sending direct message to player %s: %v", player.ID, err)
+		}
+	}
+}
+
+// deliverUnreadDirect sends the player every direct message they have not read
+// yet in the space they are connected to.
+func (gs *SpaceServer) deliverUnreadDirect(player *Player) {
+	if player.Status == models.UserStatusDND {
+		return
+	}
+
+	messages := []DirectMessage{}
+	if err := gs.db.Model(&models.DirectMessage{}).
+		Select(`direct_messages.id, direct_messages.created_at AS time, direct_messages.space_id, direct_messages.sender_id,
+			COALESCE(users.nickname, '') AS sender, direct_messages.recipient_id, direct_messages.content`).
+		Joins("LEFT JOIN users ON users.id = direct_messages.sender_id").
+		Where("direct_messages.space_id = ? AND direct_messages.recipient_id = ? AND direct_messages.read_at IS NULL", player.SpaceID, player.ID).
+		Order("direct_messages.id").
+		Limit(unreadDirectLimit).
+		Scan(&messages).Error; err != nil {
+		log.Errorf("Error loading unread direct messages: %v", err)
+		return
+	}
+	if len(messages) == 0 {
+		return
+	}
+
+	if err := player.send(Message{
+		Type:    "direct_messages_unread",
+		Content: messages,
+		Time:    time.Now(),
+	}); err != nil {
+		log.Errorf("Error sending unread direct messages: %v", err)
+	}
+}
+
+// markDirectRead marks the player's messages in their space up to and including
+// messageID as read.
+func (gs *SpaceServer) markDirectRead(player *Player, messageID uint) error {
+	return gs.db.Model(&models.DirectMessage{}).
+		Where("space_id = ? AND recipient_id = ? AND id <= ? AND read_at IS NULL", player.SpaceID, player.ID, messageID).
+		Update("read_at", time.Now()).Error
+}
+
+// sendDirectError tells the player why their direct message was not sent.
+func sendDirectError(player *Player, recipientID uint, err error) {
+	reason := "Failed to send the message"
+	if errors.Is(err, errDirectRecipient) {
+		reason = "Choose another member of this space"
+	} else if errors.Is(err, errSpaceArchived) {
+		reason = "This space is archived"
+	} else {
+		log.Errorf("Error sending direct message to %d: %v", recipientID, err)
+	}
+
+	if err := player.send(Message{
+		Type: "direct_error",
+		Content: map[string]interface{}{
+			"recipient_id": recipientID,
+			"error":        reason,
+		},
+		Time: time.Now(),
+	}); err != nil {
+		log.Errorf("Error sending direct error to player %s: %v", player.ID, err)
+	}
+}
@@ -0,0 +1,226 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+const (
+	kanbanEventsChannel = "kanban:events"
+	// Number of events kept per space so reconnecting clients can catch up
+	kanbanStreamMaxLen = 500
+	kanbanStreamTTL    = 7 * 24 * time.Hour
+	kanbanBufferSize   = 64
+)
+
+type KanbanEvent struct {
+	ID      string          `json:"id"`
+	SpaceID uint            `json:"space_id"`
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data"`
+	Time    time.Time       `json:"time"`
+}
+
+type KanbanServer struct {
+	redisClient *redis.Client
+	ctx         context.Context
+	subscribers map[uint]map[chan KanbanEvent]struct{}
+	mu          sync.RWMutex
+}
+
+var (
+	kanbanServer *KanbanServer
+	kanbanOnce   sync.Once
+)
+
+func NewKanbanServer() *KanbanServer {
+	kanbanOnce.Do(func() {
+		kanbanServer = &KanbanServer{
+			redisClient: RedisClient,
+			ctx:         context.Background(),
+			subscribers: make(map[uint]map[chan KanbanEvent]struct{}),
+		}
+
+		go kanbanServer.subscribeToRedis()
+	})
+
+	return kanbanServer
+}
+
+func kanbanStreamKey(spaceID uint) string {
+	return fmt.Sprintf("kanban:stream:%d", spaceID)
+}
+
+// Publish appends the event to the space's Redis stream, which assigns the
+// event ID used for Last-Event-ID resumption, and fans it out to every replica.
+func (ks *KanbanServer) Publish(spaceID uint, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("error marshaling kanban event: %v", err)
+	}
+
+	now := time.Now()
+	streamKey := kanbanStreamKey(spaceID)
+
+	id, err := ks.redisClient.XAdd(ks.ctx, &redis.XAddArgs{
+		Stream: streamKey,
+		MaxLen: kanbanStreamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"type": eventType,
+			"data": string(payload),
+			"time": now.Format(time.RFC3339Nano),
+		},
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("error appending kanban event to stream: %v", err)
+	}
+	ks.redisClient.Expire(ks.ctx, streamKey, kanbanStreamTTL)
+
+	event := KanbanEvent{
+		ID:      id,
+		SpaceID: spaceID,
+		Type:    eventType,
+		Data:    payload,
+		Time:    now,
+	}
+
+	jsonEvent, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshaling kanban event: %v", err)
+	}
+
+	if err := ks.redisClient.Publish(ks.ctx, kanbanEventsChannel, jsonEvent).Err(); err != nil {
+		return fmt.Errorf("error publishing kanban event to Redis: %v", err)
+	}
+
+	return nil
+}
+
+// EventsSince returns the events recorded for a space after lastID, oldest first.
+func (ks *KanbanServer) EventsSince(spaceID uint, lastID string) ([]KanbanEvent, error) {
+	messages, err := ks.redisClient.XRange(ks.ctx, kanbanStreamKey(spaceID), lastID, "+").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]KanbanEvent, 0, len(messages))
+	for _, msg := range messages {
+		if msg.ID == lastID {
+			continue
+		}
+
+		event := KanbanEvent{ID: msg.ID, SpaceID: spaceID}
+		if eventType, ok := msg.Values["type"].(string); ok {
+			event.Type = eventType
+		}
+		if data, ok := msg.Values["data"].(string); ok {
+			event.Data = json.RawMessage(data)
+		}
+		if eventTime, ok := msg.Values["time"].(string); ok {
+			event.Time, _ = time.Parse(time.RFC3339Nano, eventTime)
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// Subscribe registers a local listener for a space. The returned channel is
+// closed if the listener falls behind, so the client reconnects and resumes.
+func (ks *KanbanServer) Subscribe(spaceID uint) (<-chan KanbanEvent, func()) {
+	ch := make(chan KanbanEvent, kanbanBufferSize)
+
+	ks.mu.Lock()
+	if ks.subscribers[spaceID] == nil {
+		ks.subscribers[spaceID] = make(map[chan KanbanEvent]struct{})
+	}
+	ks.subscribers[spaceID][ch] = struct{}{}
+	ks.mu.Unlock()
+
+	unsubscribe := func() {
+		ks.mu.Lock()
+		defer ks.mu.Unlock()
+		ks.removeSubscriber(spaceID, ch)
+	}
+
+	return ch, unsubscribe
+}
+
+// removeSubscriber must be called with ks.mu held.
+func (ks *KanbanServer) removeSubscriber(spaceID uint, ch chan KanbanEvent) {
+	subscribers, exists := ks.subscribers[spaceID]
+	if !exists {
+		return
+	}
+	if _, exists := subscribers[ch]; !exists {
+		return
+	}
+
+	delete(subscribers, ch)
+	close(ch)
+
+	if len(subscribers) == 0 {
+		delete(ks.subscribers, spaceID)
+	}
+}
+
+func (ks *KanbanServer) subscribeToRedis() {
+	pubsub := ks.redisClient.Subscribe(ks.ctx, kanbanEventsChannel)
+	defer pubsub.Close()
+
+	ch := pubsub.Channel()
+	for msg := range ch {
+		var event KanbanEvent
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+			log.Errorf("Error parsing kanban event: %v", err)
+			continue
+		}
+
+		ks.mu.Lock()
+		for subscriber := range ks.subscribers[event.SpaceID] {
+			select {
+			case subscriber <- event:
+			default:
+				log.Warnf("Kanban subscriber for space %d is lagging, dropping connection", event.SpaceID)
+				ks.removeSubscriber(event.SpaceID, subscriber)
+			}
+		}
+		ks.mu.Unlock()
+	}
+}
+
+// StreamIDAfter reports whether Redis stream ID a sorts after b.
+func StreamIDAfter(a, b string) bool {
+	if b == "" {
+		return true
+	}
+
+	aMs, aSeq, _ := parseStreamID(a)
+	bMs, bSeq, _ := parseStreamID(b)
+	if aMs != bMs {
+		return aMs > bMs
+	}
+	return aSeq > bSeq
+}
+
+// ValidStreamID reports whether id is a complete Redis stream ID, <ms>-<seq>.
+func ValidStreamID(id string) bool {
+	_, _, ok := parseStreamID(id)
+	return ok
+}
+
+func parseStreamID(id string) (uint64, uint64, bool) {
+	msPart, seqPart, found := strings.Cut(id, "-")
+	ms, msErr := strconv.ParseUint(msPart, 10, 64)
+	seq, seqErr := strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq, found && msErr == nil && seqErr == nil
+}
@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bhav-07/haven/models"
+	"github.com/go-redis/redis/v8"
+)
+
+// Changes to a space that must reach the live connections of every replica
+// are published on this channel.
+const spaceLifecycleChannel = "space:lifecycle"
+
+const (
+	LifecycleMemberRemoved   = "member_removed"
+	LifecycleSpaceArchived   = "space_archived"
+	LifecycleSpaceUnarchived = "space_unarchived"
+	LifecycleSpaceDeleted    = "space_deleted"
+	LifecycleRoleChanged     = "role_changed"
+)
+
+// LifecycleEvent tells every replica about a change to a space that affects
+// its live connections. A zero UserID applies to everyone in the space. Role
+// is the user's new role for role_changed events.
+type LifecycleEvent struct {
+	Type    string           `json:"type"`
+	SpaceID uint             `json:"space_id"`
+	UserID  uint             `json:"user_id,omitempty"`
+	Role    models.SpaceRole `json:"role,omitempty"`
+}
+
+func (e LifecycleEvent) appliesTo(userID uint) bool {
+	return e.UserID == 0 || e.UserID == userID
+}
+
+// disconnects reports whether the affected users lose access to the space.
+func (e LifecycleEvent) disconnects() bool {
+	return e.Type == LifecycleMemberRemoved || e.Type == LifecycleSpaceDeleted
+}
+
+func PublishLifecycleEvent(redisClient *redis.Client, event LifecycleEvent) error {
+	jsonMessage, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshaling lifecycle event: %v", err)
+	}
+
+	err = redisClient.Publish(context.Background(), spaceLifecycleChannel, jsonMessage).Err()
+	if err != nil {
+		return fmt.Errorf("error publishing lifecycle event to Redis: %v", err)
+	}
+
+	return nil
+}
+
+func parseLifecycleEvent(payload string) (LifecycleEvent, error) {
+	var event LifecycleEvent
+	err := json.Unmarshal([]byte(payload), &event)
+	return event, err
+}
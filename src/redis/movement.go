@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"math"
+	"time"
+
+	"github.com/bhav-07/haven/maps"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+const (
+	// moveSlack absorbs network jitter on top of the distance the speed allows
+	moveSlack = 48
+	// maxMoveWindow caps the time credited to a move, so idling does not
+	// save up distance for a jump
+	maxMoveWindow = time.Second
+)
+
+// movePlayer moves the player to the position when they could have walked
+// there since their last move, without crossing a wall. It reports whether
+// the move was accepted.
+func (gs *SpaceServer) movePlayer(player *Player, target Position) bool {
+	now := time.Now()
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	elapsed := now.Sub(player.MovedAt)
+	if elapsed > maxMoveWindow {
+		elapsed = maxMoveWindow
+	}
+	distance := math.Hypot(target.X-player.Position.X, target.Y-player.Position.Y)
+	if distance > gs.maxPlayerSpeed*elapsed.Seconds()+moveSlack {
+		log.Warnf("Rejected move of player %s over %.0fpx in %v", player.ID, distance, elapsed)
+		return false
+	}
+
+	from := maps.Point{X: player.Position.X, Y: player.Position.Y}
+	if player.Map.PathBlocked(from, maps.Point{X: target.X, Y: target.Y}) {
+		return false
+	}
+
+	player.Position = target
+	player.MovedAt = now
+	return true
+}
+
+// sendPositionCorrection puts the player back at the last accepted position.
+func sendPositionCorrection(player *Player) {
+	if err := player.send(Message{
+		Type:    "position_corrected",
+		Content: map[string]interface{}{"position": player.Position},
+		Time:    time.Now(),
+	}); err != nil {
+		log.Errorf("Error sending position correction to player %s: %v", player.ID, err)
+	}
+}
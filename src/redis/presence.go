@@ -0,0 +1,276 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/bhav-07/haven/models"
+	"github.com/go-redis/redis/v8"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// Every replica records the players connected through it in a Redis hash per
+// space, keyed by user ID, so presence can be read without joining the space.
+const presenceKeyPrefix = "space:presence:"
+
+const (
+	// presenceHeartbeat is how often a replica refreshes the entries of its players
+	presenceHeartbeat = 10 * time.Second
+	// presenceTTL is how long an entry lives without a heartbeat before it is
+	// reaped, long enough to survive a couple of missed heartbeats.
+	presenceTTL = 3 * presenceHeartbeat
+)
+
+type PresenceEntry struct {
+	UserID     string            `json:"user_id"`
+	Name       string            `json:"name"`
+	Nickname   string            `json:"nickname"`
+	Position   Position          `json:"position"`
+	Status     models.UserStatus `json:"status"`
+	ZoneID     uint              `json:"zone_id,omitempty"`
+	JoinedAt   time.Time         `json:"joined_at"`
+	InstanceID string            `json:"instance_id"`
+	SessionID  string            `json:"session_id"`
+	Heartbeat  time.Time         `json:"heartbeat"`
+}
+
+func (e PresenceEntry) expired(now time.Time) bool {
+	return now.Sub(e.Heartbeat) > presenceTTL
+}
+
+func (e PresenceEntry) player(spaceID string) Player {
+	return Player{
+		ID:       e.UserID,
+		Name:     e.Name,
+		SpaceID:  spaceID,
+		Nickname: e.Nickname,
+		Position: e.Position,
+		Status:   e.Status,
+		ZoneID:   e.ZoneID,
+		JoinedAt: e.JoinedAt,
+	}
+}
+
+// removePresenceScript deletes an entry only if it still belongs to the given
+// session, so a player who reconnected elsewhere is not removed by their old
+// connection, and only one replica wins when several reap the same entry.
+var removePresenceScript = redis.NewScript(`
+local entry = redis.call('HGET', KEYS[1], ARGV[1])
+if entry and cjson.decode(entry).session_id == ARGV[2] then
+	return redis.call('HDEL', KEYS[1], ARGV[1])
+end
+return 0
+`)
+
+func presenceKey(spaceID string) string {
+	return presenceKeyPrefix + spaceID
+}
+
+func newPresenceID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+func newInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "haven"
+	}
+	return hostname + "-" + newPresenceID()
+}
+
+func (gs *SpaceServer) presenceEntry(player *Player, now time.Time) PresenceEntry {
+	return PresenceEntry{
+		UserID:     player.ID,
+		Name:       player.Name,
+		Nickname:   player.Nickname,
+		Position:   player.Position,
+		Status:     player.Status,
+		ZoneID:     player.ZoneID,
+		JoinedAt:   player.JoinedAt,
+		InstanceID: gs.instanceID,
+		SessionID:  player.SessionID,
+		Heartbeat:  now,
+	}
+}
+
+func (gs *SpaceServer) setPresence(player *Player) error {
+	return gs.writePresence(player.SpaceID, gs.presenceEntry(player, time.Now()))
+}
+
+// writePresence records an entry built beforehand, so callers holding gs.mu
+// can snapshot the player and talk to Redis after releasing the lock.
+func (gs *SpaceServer) writePresence(spaceID string, presence PresenceEntry) error {
+	entry, err := json.Marshal(presence)
+	if err != nil {
+		return err
+	}
+
+	key := presenceKey(spaceID)
+	pipe := gs.redisClient.TxPipeline()
+	pipe.HSet(gs.ctx, key, presence.UserID, entry)
+	// Spaces whose players were all on crashed replicas expire on their own
+	pipe.Expire(gs.ctx, key, presenceTTL)
+	_, err = pipe.Exec(gs.ctx)
+	return err
+}
+
+// removePresence reports whether the entry of the player's session was removed.
+func (gs *SpaceServer) removePresence(player *Player) (bool, error) {
+	removed, err := removePresenceScript.Run(gs.ctx, gs.redisClient, []string{presenceKey(player.SpaceID)}, player.ID, player.SessionID).Int()
+	return removed == 1, err
+}
+
+// heartbeatPresence refreshes the entries of the players connected to this
+// replica, with their latest position and status.
+func (gs *SpaceServer) heartbeatPresence() {
+	now := time.Now()
+
+	gs.mu.RLock()
+	entries := make(map[string][]PresenceEntry, len(gs.spaces))
+	for spaceID, players := range gs.spaces {
+		for _, player := range players {
+			entries[spaceID] = append(entries[spaceID], gs.presenceEntry(player, now))
+		}
+	}
+	gs.mu.RUnlock()
+
+	if len(entries) == 0 {
+		return
+	}
+
+	pipe := gs.redisClient.Pipeline()
+	for spaceID, spaceEntries := range entries {
+		key := presenceKey(spaceID)
+		for _, entry := range spaceEntries {
+			value, err := json.Marshal(entry)
+			if err != nil {
+				continue
+			}
+			pipe.HSet(gs.ctx, key, entry.UserID, value)
+		}
+		pipe.Expire(gs.ctx, key, presenceTTL)
+	}
+	if _, err := pipe.Exec(gs.ctx); err != nil {
+		log.Errorf("Error refreshing presence: %v", err)
+	}
+}
+
+// reapPresence removes the entries that missed their heartbeats, left behind
+// by replicas that crashed, and announces those players as gone.
+func (gs *SpaceServer) reapPresence() {
+	now := time.Now()
+
+	iter := gs.redisClient.Scan(gs.ctx, 0, presenceKeyPrefix+"*", 100).Iterator()
+	for iter.Next(gs.ctx) {
+		key := iter.Val()
+		spaceID := key[len(presenceKeyPrefix):]
+
+		values, err := gs.redisClient.HGetAll(gs.ctx, key).Result()
+		if err != nil {
+			log.Errorf("Error reading presence: %v", err)
+			continue
+		}
+
+		for _, value := range values {
+			var entry PresenceEntry
+			if err := json.Unmarshal([]byte(value), &entry); err != nil || !entry.expired(now) {
+				continue
+			}
+
+			player := entry.player(spaceID)
+			player.SessionID = entry.SessionID
+			removed, err := gs.removePresence(&player)
+			if err != nil {
+				log.Errorf("Error reaping presence: %v", err)
+				continue
+			}
+			if !removed {
+				// Another replica reaped it first, or the player came back
+				continue
+			}
+
+			log.Warnf("Reaped player %s in space %s left by instance %s", entry.UserID, spaceID, entry.InstanceID)
+			gs.publishToRedis("game:events", "player_left", map[string]interface{}{
+				"player_id":       entry.UserID,
+				"player_name":     entry.Name,
+				"space_id":        spaceID,
+				"player_nickname": entry.Nickname,
+			})
+		}
+	}
+	if err := iter.Err(); err != nil {
+		log.Errorf("Error scanning presence: %v", err)
+	}
+}
+
+func (gs *SpaceServer) maintainPresence() {
+	ticker := time.NewTicker(presenceHeartbeat)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		gs.heartbeatPresence()
+		gs.reapPresence()
+	}
+}
+
+// spacePlayers lists the players connected to the space on any replica.
+func spacePlayers(ctx context.Context, redisClient *redis.Client, spaceID string) ([]PresenceEntry, error) {
+	values, err := redisClient.HGetAll(ctx, presenceKey(spaceID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entries := make([]PresenceEntry, 0, len(values))
+	for _, value := range values {
+		var entry PresenceEntry
+		if err := json.Unmarshal([]byte(value), &entry); err != nil || entry.expired(now) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].JoinedAt.Before(entries[j].JoinedAt)
+	})
+
+	return entries, nil
+}
+
+// SpacePresence lists the players connected to the space on any replica.
+func SpacePresence(redisClient *redis.Client, spaceID uint) ([]PresenceEntry, error) {
+	return spacePlayers(context.Background(), redisClient, fmt.Sprintf("%d", spaceID))
+}
+
+// PresenceCounts returns how many players are connected to each space. Entries
+// of crashed replicas are counted until they are reaped.
+func PresenceCounts(redisClient *redis.Client, spaceIDs []uint) (map[uint]int64, error) {
+	ctx := context.Background()
+
+	pipe := redisClient.Pipeline()
+	commands := make(map[uint]*redis.IntCmd, len(spaceIDs))
+	for _, spaceID := range spaceIDs {
+		commands[spaceID] = pipe.HLen(ctx, presenceKey(fmt.Sprintf("%d", spaceID)))
+	}
+	if len(commands) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	counts := make(map[uint]int64, len(commands))
+	for spaceID, cmd := range commands {
+		counts[spaceID] = cmd.Val()
+	}
+	return counts, nil
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/bhav-07/haven/config"
+	"github.com/bhav-07/haven/maps"
 	"github.com/bhav-07/haven/models"
 	"github.com/bhav-07/haven/utils"
 	"github.com/go-redis/redis/v8"
@@ -26,12 +29,6 @@ type Position struct {
 	Y float64 `json:"y"`
 }
 
-type ChatMessage struct {
-	Time    time.Time `json:"time"`
-	Content string    `json:"content"`
-	Author  string    `json:"author"`
-}
-
 type Player struct {
 	ID       string            `json:"id"`
 	Name     string            `json:"name"`
@@ -40,6 +37,23 @@ type Player struct {
 	Position Position          `json:"position"`
 	Conn     *websocket.Conn   `json:"-"`
 	Status   models.UserStatus `json:"status"`
+	JoinedAt time.Time         `json:"-"`
+	Role     models.SpaceRole  `json:"-"`
+	MovedAt  time.Time         `json:"-"`
+	Map      *maps.Map         `json:"-"`
+	ZoneID   uint              `json:"zone_id,omitempty"`
+	// Tells this connection apart from later ones of the same user
+	SessionID string `json:"-"`
+	// The connection allows a single writer at a time, see send
+	writeMu sync.Mutex
+}
+
+// send writes a message to the player's connection. The reader goroutine and
+// the Redis subscriber both write to players, so every write goes through here.
+func (p *Player) send(message interface{}) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	return p.Conn.WriteJSON(message)
 }
 
 type SpaceServer struct {
@@ -47,30 +61,48 @@ type SpaceServer struct {
 	ctx         context.Context
 	spaces      map[string]map[string]*Player
 	mu          sync.RWMutex
-	spaceChats  map[string][]ChatMessage
+	db          *gorm.DB
+	instanceID  string
+	// zones caches the zones of every space with players on this replica
+	zones           map[string][]models.SpaceZone
+	proximityRadius float64
+	maxPlayerSpeed  float64
+	// archived tracks which spaces with players here are read-only
+	archived map[string]bool
 }
 
-func NewSpaceServer() (*SpaceServer, error) {
+func NewSpaceServer(db *gorm.DB) (*SpaceServer, error) {
 	ctx := context.Background()
 
 	gs := &SpaceServer{
-		redisClient: RedisClient,
-		ctx:         ctx,
-		spaces:      make(map[string]map[string]*Player),
-		spaceChats:  make(map[string][]ChatMessage),
+		redisClient:     RedisClient,
+		ctx:             ctx,
+		spaces:          make(map[string]map[string]*Player),
+		db:              db,
+		instanceID:      newInstanceID(),
+		zones:           make(map[string][]models.SpaceZone),
+		archived:        make(map[string]bool),
+		proximityRadius: config.GetProximityRadius(),
+		maxPlayerSpeed:  config.GetMaxPlayerSpeed(),
 	}
 
 	go gs.subscribeToRedis()
+	go gs.maintainPresence()
 
 	return gs, nil
 }
 
 func (gs *SpaceServer) subscribeToRedis() {
-	pubsub := gs.redisClient.Subscribe(gs.ctx, "game:positions", "game:events", "user:status_updates", "game:chat")
+	pubsub := gs.redisClient.Subscribe(gs.ctx, "game:positions", "game:events", "user:status_updates", "game:chat", directChannel, spaceLifecycleChannel)
 	defer pubsub.Close()
 
 	ch := pubsub.Channel()
 	for msg := range ch {
+		if msg.Channel == spaceLifecycleChannel {
+			gs.handleLifecycleEvent(msg.Payload)
+			continue
+		}
+
 		var message Message
 		if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
 			log.Error("Error parsing Redis message: %v", err)
@@ -82,12 +114,60 @@ func (gs *SpaceServer) subscribeToRedis() {
 			gs.handleStatusUpdate(message)
 		case "game:chat":
 			gs.handleChatMessage(message)
+		case directChannel:
+			gs.handleDirectMessage(message)
 		default:
+			switch message.Type {
+			case "member_role_changed", "ownership_transferred":
+				gs.handleRoleChange(message)
+			case "zones_updated":
+				gs.handleZonesUpdated(message)
+			}
 			gs.broadcastToSpacePlayers(message)
 		}
 	}
 }
 
+// handleRoleChange keeps the role of connected players current, so chat
+// moderation follows promotions and demotions without reconnecting.
+func (gs *SpaceServer) handleRoleChange(message Message) {
+	content, ok := message.Content.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	spaceID, ok := content["space_id"].(string)
+	if !ok {
+		return
+	}
+
+	roles := map[string]models.SpaceRole{}
+	if message.Type == "ownership_transferred" {
+		if ownerID, ok := content["owner_id"].(float64); ok {
+			roles[fmt.Sprintf("%d", uint(ownerID))] = models.SpaceRoleOwner
+		}
+		if previousID, ok := content["previous_owner_id"].(float64); ok {
+			roles[fmt.Sprintf("%d", uint(previousID))] = models.SpaceRoleAdmin
+		}
+	} else {
+		userID, ok := content["user_id"].(float64)
+		role, hasRole := content["role"].(string)
+		if !ok || !hasRole {
+			return
+		}
+		roles[fmt.Sprintf("%d", uint(userID))] = models.SpaceRole(role)
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	for userID, role := range roles {
+		if player, exists := gs.spaces[spaceID][userID]; exists {
+			player.Role = role
+		}
+	}
+}
+
 func (gs *SpaceServer) handleStatusUpdate(message Message) {
 	content, ok := message.Content.(map[string]interface{})
 	if !ok {
@@ -105,11 +185,19 @@ func (gs *SpaceServer) handleStatusUpdate(message Message) {
 	}
 
 	gs.mu.Lock()
-	defer gs.mu.Unlock()
+	// Players leaving do not disturb get the direct messages held back meanwhile
+	var undisturbed []*Player
+	// Presence is written once the lock is released
+	presence := make(map[string]PresenceEntry)
+	now := time.Now()
 
 	for spaceID, players := range gs.spaces {
 		if player, exists := players[userIdStr]; exists {
+			if player.Status == models.UserStatusDND && models.UserStatus(newStatus) != models.UserStatusDND {
+				undisturbed = append(undisturbed, player)
+			}
 			player.Status = models.UserStatus(newStatus)
+			presence[spaceID] = gs.presenceEntry(player, now)
 
 			statusUpdateMessage := Message{
 				Type: "status_update",
@@ -124,15 +212,32 @@ func (gs *SpaceServer) handleStatusUpdate(message Message) {
 			}
 
 			for _, p := range players {
-				if err := p.Conn.WriteJSON(statusUpdateMessage); err != nil {
+				if err := p.send(statusUpdateMessage); err != nil {
 					log.Error("Error sending status update to player %s: %v", p.ID, err)
 				}
 			}
 		}
 	}
+	gs.mu.Unlock()
+
+	for spaceID, entry := range presence {
+		if err := gs.writePresence(spaceID, entry); err != nil {
+			log.Errorf("Error recording presence: %v", err)
+		}
+	}
+
+	for _, player := range undisturbed {
+		gs.deliverUnreadDirect(player)
+	}
 }
 
 func (gs *SpaceServer) handleChatMessage(message Message) {
+	// Edits, deletions and reactions go out as they were published
+	if message.Type != "chat_message" {
+		gs.broadcastToSpacePlayers(message)
+		return
+	}
+
 	content, ok := message.Content.(map[string]interface{})
 	if !ok {
 		return
@@ -143,39 +248,83 @@ func (gs *SpaceServer) handleChatMessage(message Message) {
 		return
 	}
 
-	chatMsg := ChatMessage{
-		Time:    time.Now(),
-		Content: content["content"].(string),
-		Author:  content["author"].(string),
+	// The message was already stored by the replica of its author
+	var chatMsg ChatMessage
+	payload, err := json.Marshal(content)
+	if err != nil {
+		return
 	}
-
-	gs.mu.Lock()
-	defer gs.mu.Unlock()
-
-	if _, exists := gs.spaceChats[spaceID]; !exists {
-		gs.spaceChats[spaceID] = []ChatMessage{}
+	if err := json.Unmarshal(payload, &chatMsg); err != nil {
+		log.Errorf("Error parsing chat message: %v", err)
+		return
 	}
 
-	messages := append(gs.spaceChats[spaceID], chatMsg)
-	if len(messages) > 50 {
-		messages = messages[len(messages)-50:]
-	}
-	gs.spaceChats[spaceID] = messages
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
 
 	if players, exists := gs.spaces[spaceID]; exists {
-		chatMsg := Message{
+		outgoing := Message{
 			Type:    "chat_message",
 			Content: chatMsg,
 			Time:    time.Now(),
 		}
 		for _, player := range players {
-			if err := player.Conn.WriteJSON(chatMsg); err != nil {
+			if !gs.inChatReach(player, chatMsg) {
+				continue
+			}
+			if err := player.send(outgoing); err != nil {
 				log.Error("Error sending chat to player %s: %v", player.ID, err)
 			}
 		}
 	}
 }
 
+// handleLifecycleEvent keeps the archived flag of spaces in sync and closes the
+// connections of users who lost access to a space. handlePlayerMessages then
+// cleans up and announces player_left.
+func (gs *SpaceServer) handleLifecycleEvent(payload string) {
+	event, err := parseLifecycleEvent(payload)
+	if err != nil {
+		log.Errorf("Error parsing lifecycle event: %v", err)
+		return
+	}
+
+	spaceID := fmt.Sprintf("%d", event.SpaceID)
+
+	// Archived spaces can still be visited, but chat and direct messages are read-only
+	switch event.Type {
+	case LifecycleSpaceArchived, LifecycleSpaceUnarchived:
+		gs.mu.Lock()
+		if _, exists := gs.spaces[spaceID]; exists {
+			gs.archived[spaceID] = event.Type == LifecycleSpaceArchived
+		}
+		gs.mu.Unlock()
+	}
+
+	if !event.disconnects() {
+		return
+	}
+
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	for _, player := range gs.spaces[spaceID] {
+		playerID, err := strconv.ParseUint(player.ID, 10, 64)
+		if err != nil || !event.appliesTo(uint(playerID)) {
+			continue
+		}
+
+		if err := player.send(Message{
+			Type:    event.Type,
+			Content: map[string]interface{}{"space_id": spaceID},
+			Time:    time.Now(),
+		}); err != nil {
+			log.Errorf("Error notifying player %s: %v", player.ID, err)
+		}
+		player.Conn.Close()
+	}
+}
+
 func PublishStatusUpdate(redisClient *redis.Client, userID uint, status models.UserStatus) error {
 	ctx := context.Background()
 
@@ -201,6 +350,31 @@ func PublishStatusUpdate(redisClient *redis.Client, userID uint, status models.U
 	return nil
 }
 
+// PublishSpaceEvent sends an event to every player connected to the space,
+// whichever replica they are connected to.
+func PublishSpaceEvent(redisClient *redis.Client, spaceID uint, messageType string, content map[string]interface{}) error {
+	ctx := context.Background()
+
+	content["space_id"] = fmt.Sprintf("%d", spaceID)
+	message := Message{
+		Type:    messageType,
+		Content: content,
+		Time:    time.Now(),
+	}
+
+	jsonMessage, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("error marshaling space event: %v", err)
+	}
+
+	err = redisClient.Publish(ctx, "game:events", jsonMessage).Err()
+	if err != nil {
+		return fmt.Errorf("error publishing space event to Redis: %v", err)
+	}
+
+	return nil
+}
+
 func (gs *SpaceServer) broadcastToSpacePlayers(message Message) {
 	gs.mu.RLock()
 	defer gs.mu.RUnlock()
@@ -216,7 +390,7 @@ func (gs *SpaceServer) broadcastToSpacePlayers(message Message) {
 	}
 
 	for _, player := range gs.spaces[spaceID] {
-		err := player.Conn.WriteJSON(message)
+		err := player.send(message)
 		if err != nil {
 			log.Error("Error sending to player %s: %v", player.ID, err)
 		}
@@ -239,6 +413,10 @@ func (gs *SpaceServer) HandleWebSocket(c *websocket.Conn, db *gorm.DB) {
 	}
 
 	spaceIdstring := c.Params("id")
+	role, _ := c.Locals("spaceRole").(models.SpaceRole)
+	space, _ := c.Locals("space").(models.Space)
+	gameMap := maps.Get(space.Map)
+	spawn := gameMap.Spawn()
 	// spaceId, err := strconv.ParseUint(spaceIdstring, 10, 64)
 	// if err != nil {
 	// 	log.Warn("Unable to convert string to uint")
@@ -247,50 +425,81 @@ func (gs *SpaceServer) HandleWebSocket(c *websocket.Conn, db *gorm.DB) {
 	userIdStr := fmt.Sprintf("%d", userId)
 
 	player := &Player{
-		ID:       userIdStr,
-		Name:     user.Name,
-		SpaceID:  spaceIdstring,
-		Nickname: user.Nickname,
-		Conn:     c,
-		Position: Position{X: 850, Y: 1040},
-		Status:   user.Status,
+		ID:        userIdStr,
+		Name:      user.Name,
+		SpaceID:   spaceIdstring,
+		Nickname:  user.Nickname,
+		Conn:      c,
+		Position:  Position{X: spawn.X, Y: spawn.Y},
+		Status:    user.Status,
+		JoinedAt:  time.Now(),
+		MovedAt:   time.Now(),
+		Map:       gameMap,
+		SessionID: newPresenceID(),
+		Role:      role,
 	}
 
-	gs.mu.Lock()
-	if gs.spaces[spaceIdstring] == nil {
-		gs.spaces[spaceIdstring] = make(map[string]*Player)
-		gs.spaceChats[spaceIdstring] = []ChatMessage{}
+	// Players on every replica are listed from Redis, not just the local ones
+	currentPlayersInSpace := []Player{}
+	presence, err := spacePlayers(gs.ctx, gs.redisClient, spaceIdstring)
+	if err != nil {
+		log.Errorf("Error reading presence: %v", err)
 	}
-
-	var currentPlayersInSpace []Player
-	for userId, players := range gs.spaces[spaceIdstring] {
-		if userId != userIdStr {
+	for _, entry := range presence {
+		if entry.UserID != userIdStr {
 			//Send all players info except user themselves
-			currentPlayersInSpace = append(currentPlayersInSpace, *players)
+			currentPlayersInSpace = append(currentPlayersInSpace, entry.player(spaceIdstring))
 		}
 	}
+
+	gs.mu.Lock()
+	if gs.spaces[spaceIdstring] == nil {
+		gs.spaces[spaceIdstring] = make(map[string]*Player)
+		gs.archived[spaceIdstring] = space.ArchivedAt != nil
+	}
 	gs.spaces[spaceIdstring][userIdStr] = player
-	chatHistory := gs.spaceChats[spaceIdstring]
 	gs.mu.Unlock()
 
+	gs.loadZones(spaceIdstring)
+	gs.updatePlayerZone(player)
+
+	if err := gs.setPresence(player); err != nil {
+		log.Errorf("Error recording presence: %v", err)
+	}
+
+	chatHistory, err := ChatHistory(gs.db, space.ID, 0, chatHistoryLimit)
+	if err != nil {
+		log.Errorf("Error loading chat history: %v", err)
+	}
 	if len(chatHistory) > 0 {
 		historyMsg := Message{
 			Type:    "chat_history",
 			Content: chatHistory,
 			Time:    time.Now(),
 		}
-		if err := player.Conn.WriteJSON(historyMsg); err != nil {
+		if err := player.send(historyMsg); err != nil {
 			log.Errorf("Error sending chat history: %v", err)
 		}
 	}
 
+	gs.deliverUnreadDirect(player)
+
+	// The client places its own player where the server spawned it
+	if err := player.send(Message{
+		Type:    "spawn",
+		Content: map[string]interface{}{"position": player.Position},
+		Time:    time.Now(),
+	}); err != nil {
+		log.Errorf("Error sending spawn point: %v", err)
+	}
+
 	joinMessage := map[string]interface{}{
 		"type":    "existing_players",
 		"content": currentPlayersInSpace,
 		"time":    time.Now(),
 	}
 
-	if err := player.Conn.WriteJSON(joinMessage); err != nil {
+	if err := player.send(joinMessage); err != nil {
 		log.Errorf("Error sending existing players to new player: %v", err)
 	}
 
@@ -322,9 +531,13 @@ func (gs *SpaceServer) handlePlayerMessages(player *Player) {
 		}
 
 		var message struct {
-			Type     string   `json:"type"`
-			Position Position `json:"position,omitempty"`
-			Content  string   `json:"content,omitempty"`
+			Type        string   `json:"type"`
+			Position    Position `json:"position,omitempty"`
+			Content     string   `json:"content,omitempty"`
+			MessageID   uint     `json:"message_id,omitempty"`
+			RecipientID uint     `json:"recipient_id,omitempty"`
+			Emoji       string   `json:"emoji,omitempty"`
+			Mode        string   `json:"mode,omitempty"`
 		}
 
 		if err := json.Unmarshal(msg, &message); err != nil {
@@ -334,7 +547,12 @@ func (gs *SpaceServer) handlePlayerMessages(player *Player) {
 
 		switch message.Type {
 		case "position_update":
-			player.Position = message.Position
+			if !gs.movePlayer(player, message.Position) {
+				sendPositionCorrection(player)
+				continue
+			}
+			// Presence picks up the new position on the next heartbeat
+			gs.updatePlayerZone(player)
 			gs.publishToRedis("game:positions", "position_update", map[string]interface{}{
 				"player_id":       player.ID,
 				"space_id":        player.SpaceID,
@@ -344,12 +562,29 @@ func (gs *SpaceServer) handlePlayerMessages(player *Player) {
 				"status":          player.Status,
 			})
 		case "chat_message":
-			gs.publishToRedis("game:chat", "chat_message", map[string]interface{}{
-				"space_id": player.SpaceID,
-				"content":  message.Content,
-				"author":   player.Nickname,
-			})
-			println(message.Content)
+			if err := gs.sendChatMessage(player, message.Content, message.Mode); err != nil {
+				sendChatError(player, 0, err)
+			}
+		case "chat_edit":
+			if err := gs.editChatMessage(player, message.MessageID, message.Content); err != nil {
+				sendChatError(player, message.MessageID, err)
+			}
+		case "chat_delete":
+			if err := gs.deleteChatMessage(player, message.MessageID); err != nil {
+				sendChatError(player, message.MessageID, err)
+			}
+		case "direct_message":
+			if err := gs.sendDirectMessage(player, message.RecipientID, message.Content); err != nil {
+				sendDirectError(player, message.RecipientID, err)
+			}
+		case "direct_message_read":
+			if err := gs.markDirectRead(player, message.MessageID); err != nil {
+				log.Errorf("Error marking direct messages read: %v", err)
+			}
+		case "chat_react", "chat_unreact":
+			if err := gs.reactToChatMessage(player, message.MessageID, message.Emoji, message.Type == "chat_react"); err != nil {
+				sendChatError(player, message.MessageID, err)
+			}
 		default:
 			log.Warnf("Unknown message type received: %s", message.Type)
 		}
@@ -357,17 +592,37 @@ func (gs *SpaceServer) handlePlayerMessages(player *Player) {
 	}
 }
 
+// spaceArchived reports whether the space is read-only.
+func (gs *SpaceServer) spaceArchived(spaceID string) bool {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.archived[spaceID]
+}
+
 func (gs *SpaceServer) handlePlayerDisconnect(player *Player) {
 	gs.mu.Lock()
-	delete(gs.spaces[player.SpaceID], player.ID)
+	// A newer connection of the same user may have replaced this one
+	if gs.spaces[player.SpaceID][player.ID] == player {
+		delete(gs.spaces[player.SpaceID], player.ID)
+	}
 
 	if len(gs.spaces[player.SpaceID]) == 0 {
 		delete(gs.spaces, player.SpaceID)
+		delete(gs.zones, player.SpaceID)
+		delete(gs.archived, player.SpaceID)
 	}
 	gs.mu.Unlock()
 
 	player.Conn.Close()
 
+	removed, err := gs.removePresence(player)
+	if err != nil {
+		log.Errorf("Error clearing presence: %v", err)
+	} else if !removed {
+		// The player is still connected through a newer session
+		return
+	}
+
 	gs.publishToRedis("game:events", "player_left", map[string]interface{}{
 		"player_id":       player.ID,
 		"player_name":     player.Name,
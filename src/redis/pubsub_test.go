@@ -0,0 +1,101 @@
+package redis
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	fiberws "github.com/gofiber/contrib/websocket"
+)
+
+// newTestPlayer connects a player to a websocket server and returns the
+// client end, which reads what the player is sent.
+func newTestPlayer(t *testing.T) (*Player, *websocket.Conn) {
+	t.Helper()
+
+	conns := make(chan *websocket.Conn, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrading connection: %v", err)
+			return
+		}
+		conns <- conn
+	}))
+	t.Cleanup(server.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dialing test server: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	select {
+	case conn := <-conns:
+		t.Cleanup(func() { conn.Close() })
+		return &Player{ID: "1", Conn: &fiberws.Conn{Conn: conn}}, client
+	case <-time.After(time.Second):
+		t.Fatal("server never accepted the connection")
+	}
+	return nil, nil
+}
+
+func TestPlayerSend(t *testing.T) {
+	tests := []struct {
+		name       string
+		concurrent bool
+		messages   int
+	}{
+		{"same player twice", false, 2},
+		{"many writers at once", true, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player, client := newTestPlayer(t)
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				var wg sync.WaitGroup
+				for i := 0; i < tt.messages; i++ {
+					send := func() {
+						defer wg.Done()
+						if err := player.send(Message{Type: "test"}); err != nil {
+							t.Errorf("send: %v", err)
+						}
+					}
+					wg.Add(1)
+					if tt.concurrent {
+						go send()
+					} else {
+						send()
+					}
+				}
+				wg.Wait()
+			}()
+
+			client.SetReadDeadline(time.Now().Add(2 * time.Second))
+			for i := 0; i < tt.messages; i++ {
+				var message Message
+				if err := client.ReadJSON(&message); err != nil {
+					t.Fatalf("reading message %d: %v", i+1, err)
+				}
+				if message.Type != "test" {
+					t.Errorf("message %d has type %q, want %q", i+1, message.Type, "test")
+				}
+			}
+
+			select {
+			case <-done:
+			case <-time.After(2 * time.Second):
+				t.Fatal("send never returned")
+			}
+		})
+	}
+}
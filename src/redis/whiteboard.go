@@ -26,9 +26,16 @@ type RoomParticipants struct {
 	Participants []string `json:"participants"`
 }
 
+type WhiteboardClient struct {
+	UserID   uint
+	Nickname string
+	Role     models.SpaceRole
+}
+
 type WhiteboardSpace struct {
 	SpaceID       uint
-	Clients       map[*websocket.Conn]string
+	Archived      bool
+	Clients       map[*websocket.Conn]WhiteboardClient
 	State         WhiteboardState
 	Mutex         sync.Mutex
 	lastModified  time.Time
@@ -153,11 +160,16 @@ func (ws *WhiteboardServer) saveRoomState(roomID string) {
 }
 
 func (ws *WhiteboardServer) subscribeToRedis() {
-	pubsub := ws.redisClient.Subscribe(ws.ctx, "whiteboard:updates", "whiteboard:participants")
+	pubsub := ws.redisClient.Subscribe(ws.ctx, "whiteboard:updates", "whiteboard:participants", spaceLifecycleChannel)
 	defer pubsub.Close()
 
 	ch := pubsub.Channel()
 	for msg := range ch {
+		if msg.Channel == spaceLifecycleChannel {
+			ws.handleLifecycleEvent(msg.Payload)
+			continue
+		}
+
 		var message map[string]interface{}
 		if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
 			log.Printf("Error parsing Redis message: %v", err)
@@ -201,6 +213,58 @@ func (ws *WhiteboardServer) subscribeToRedis() {
 	}
 }
 
+// handleLifecycleEvent keeps the room's archived flag and the roles of its
+// clients in sync, and closes the connections of users who lost access,
+// HandleWebSocket then removes them.
+func (ws *WhiteboardServer) handleLifecycleEvent(payload string) {
+	event, err := parseLifecycleEvent(payload)
+	if err != nil {
+		log.Printf("Error parsing lifecycle event: %v", err)
+		return
+	}
+
+	ws.mu.RLock()
+	room, exists := ws.rooms[fmt.Sprintf("%d", event.SpaceID)]
+	ws.mu.RUnlock()
+
+	if !exists {
+		return
+	}
+
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	switch event.Type {
+	case LifecycleRoleChanged:
+		for conn, client := range room.Clients {
+			if client.UserID == event.UserID {
+				client.Role = event.Role
+				room.Clients[conn] = client
+			}
+		}
+	case LifecycleSpaceArchived:
+		room.Archived = true
+	case LifecycleSpaceUnarchived:
+		room.Archived = false
+	case LifecycleSpaceDeleted:
+		// Nothing is saved for rooms without a space, the whiteboard row is already deleted
+		room.SpaceID = 0
+		if room.timer != nil {
+			room.timer.Stop()
+		}
+	}
+
+	if !event.disconnects() {
+		return
+	}
+
+	for conn, client := range room.Clients {
+		if event.appliesTo(client.UserID) {
+			conn.Close()
+		}
+	}
+}
+
 func (ws *WhiteboardServer) publishToRedis(channel string, message interface{}) {
 	jsonMessage, err := json.Marshal(message)
 	if err != nil {
@@ -228,6 +292,10 @@ func (ws *WhiteboardServer) HandleWebSocket(c *websocket.Conn) {
 	}
 
 	nickname := c.Locals("nickName").(string)
+	userId, _ := c.Locals("userId").(uint)
+
+	role, _ := c.Locals("spaceRole").(models.SpaceRole)
+	space, _ := c.Locals("space").(models.Space)
 
 	ws.mu.Lock()
 	if _, exists := ws.rooms[roomID]; !exists {
@@ -244,20 +312,21 @@ func (ws *WhiteboardServer) HandleWebSocket(c *websocket.Conn) {
 		}
 
 		ws.rooms[roomID] = &WhiteboardSpace{
-			Clients: make(map[*websocket.Conn]string),
+			Clients: make(map[*websocket.Conn]WhiteboardClient),
 			State: WhiteboardState{
 				Type:     "scene-update",
 				Elements: elements,
 				AppState: appState,
 			},
-			SpaceID: spaceID,
+			SpaceID:  spaceID,
+			Archived: space.ArchivedAt != nil,
 		}
 	}
 	room := ws.rooms[roomID]
 	ws.mu.Unlock()
 
 	room.Mutex.Lock()
-	room.Clients[c] = nickname
+	room.Clients[c] = WhiteboardClient{UserID: userId, Nickname: nickname, Role: role}
 	room.Mutex.Unlock()
 
 	defer func() {
@@ -306,6 +375,17 @@ func (ws *WhiteboardServer) HandleWebSocket(c *websocket.Conn) {
 			break
 		}
 
+		// Guests can watch the whiteboard but their edits are dropped, as is
+		// everyone's while the space is archived. Roles follow role_changed events.
+		room.Mutex.Lock()
+		canEdit := room.Clients[c].Role.Can(models.SpacePermissionEditWhiteboard)
+		archived := room.Archived
+		room.Mutex.Unlock()
+
+		if !canEdit || archived {
+			continue
+		}
+
 		if message.Type == "" {
 			message.Type = "scene-update"
 		}
@@ -328,8 +408,8 @@ func (ws *WhiteboardServer) broadcastParticipants(roomID string) {
 
 	room.Mutex.Lock()
 	participants := make([]string, 0, len(room.Clients))
-	for _, username := range room.Clients {
-		participants = append(participants, username)
+	for _, client := range room.Clients {
+		participants = append(participants, client.Nickname)
 	}
 	room.Mutex.Unlock()
 
@@ -0,0 +1,189 @@
+package redis
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/bhav-07/haven/models"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// Chat modes of a chat_message. Space chat reaches everyone and is stored,
+// proximity chat reaches players within the proximity radius of the author
+// and zone chat the players in the author's zone.
+const (
+	ChatModeSpace     = "space"
+	ChatModeProximity = "proximity"
+	ChatModeZone      = "zone"
+)
+
+// zoneAt returns the zone containing the position, the smallest one when
+// zones overlap so rooms can sit inside larger areas.
+func zoneAt(zones []models.SpaceZone, position Position) *models.SpaceZone {
+	var found *models.SpaceZone
+	for i := range zones {
+		zone := &zones[i]
+		if !zone.Contains(position.X, position.Y) {
+			continue
+		}
+		if found == nil || zone.Width*zone.Height < found.Width*found.Height {
+			found = zone
+		}
+	}
+	return found
+}
+
+// loadZones caches the zones of a space the first time a player joins it here.
+func (gs *SpaceServer) loadZones(spaceID string) {
+	gs.mu.RLock()
+	_, loaded := gs.zones[spaceID]
+	gs.mu.RUnlock()
+	if loaded {
+		return
+	}
+
+	zones := []models.SpaceZone{}
+	if err := gs.db.Where("space_id = ?", spaceID).Find(&zones).Error; err != nil {
+		log.Errorf("Error loading zones: %v", err)
+		return
+	}
+
+	gs.mu.Lock()
+	if _, exists := gs.spaces[spaceID]; exists {
+		gs.zones[spaceID] = zones
+	}
+	gs.mu.Unlock()
+}
+
+// updatePlayerZone places the player in the zone at their position and
+// announces when they enter or leave one.
+func (gs *SpaceServer) updatePlayerZone(player *Player) {
+	gs.mu.Lock()
+	zone := zoneAt(gs.zones[player.SpaceID], player.Position)
+	previous := player.ZoneID
+	var current uint
+	if zone != nil {
+		current = zone.ID
+	}
+	player.ZoneID = current
+	gs.mu.Unlock()
+
+	if previous == current {
+		return
+	}
+
+	if previous != 0 {
+		gs.publishToRedis("game:events", "zone_left", map[string]interface{}{
+			"player_id":       player.ID,
+			"player_nickname": player.Nickname,
+			"space_id":        player.SpaceID,
+			"zone_id":         previous,
+		})
+	}
+	if zone != nil {
+		gs.publishToRedis("game:events", "zone_entered", map[string]interface{}{
+			"player_id":       player.ID,
+			"player_nickname": player.Nickname,
+			"space_id":        player.SpaceID,
+			"zone_id":         zone.ID,
+			"zone_name":       zone.Name,
+		})
+	}
+}
+
+// handleZonesUpdated replaces the cached zones of a space after they were
+// edited and moves the local players into their new zones.
+func (gs *SpaceServer) handleZonesUpdated(message Message) {
+	content, ok := message.Content.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	spaceID, ok := content["space_id"].(string)
+	if !ok {
+		return
+	}
+
+	payload, err := json.Marshal(content["zones"])
+	if err != nil {
+		return
+	}
+	zones := []models.SpaceZone{}
+	if err := json.Unmarshal(payload, &zones); err != nil {
+		log.Errorf("Error parsing zones: %v", err)
+		return
+	}
+
+	gs.mu.Lock()
+	var players []*Player
+	if _, exists := gs.spaces[spaceID]; exists {
+		gs.zones[spaceID] = zones
+		for _, player := range gs.spaces[spaceID] {
+			players = append(players, player)
+		}
+	}
+	gs.mu.Unlock()
+
+	for _, player := range players {
+		gs.updatePlayerZone(player)
+	}
+}
+
+// sendScopedChatMessage relays proximity or zone chat without storing it.
+func (gs *SpaceServer) sendScopedChatMessage(player *Player, authorID uint, content string, mode string) error {
+	gs.mu.RLock()
+	position := player.Position
+	zoneID := player.ZoneID
+	gs.mu.RUnlock()
+
+	message := ChatMessage{
+		Time:     time.Now(),
+		Content:  content,
+		AuthorID: authorID,
+		Author:   player.Nickname,
+		Mode:     mode,
+	}
+	if mode == ChatModeZone {
+		if zoneID == 0 {
+			return errChatNoZone
+		}
+		message.ZoneID = zoneID
+	} else {
+		message.Position = &position
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return err
+	}
+	fields["space_id"] = player.SpaceID
+
+	gs.publishToRedis("game:chat", "chat_message", fields)
+	return nil
+}
+
+// inChatReach reports whether the player should receive the chat message.
+// Authors always get their own messages back.
+func (gs *SpaceServer) inChatReach(player *Player, message ChatMessage) bool {
+	if player.ID == strconv.FormatUint(uint64(message.AuthorID), 10) {
+		return true
+	}
+
+	switch message.Mode {
+	case ChatModeProximity:
+		if message.Position == nil {
+			return false
+		}
+		distance := math.Hypot(player.Position.X-message.Position.X, player.Position.Y-message.Position.Y)
+		return distance <= gs.proximityRadius
+	case ChatModeZone:
+		return message.ZoneID != 0 && player.ZoneID == message.ZoneID
+	}
+	return true
+}
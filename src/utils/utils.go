@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/bhav-07/haven/models"
@@ -22,6 +23,64 @@ func GetUserfromID(userId uint, db *gorm.DB) (models.User, error) {
 	return user, nil
 }
 
+func IsSpaceMember(spaceId uint, userId uint, db *gorm.DB) (bool, error) {
+	var count int64
+	err := db.Table("user_spaces").
+		Where("space_id = ? AND user_id = ? AND deleted_at IS NULL", spaceId, userId).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// EscapeLike escapes the LIKE wildcards in user input.
+func EscapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// EmailDomain returns the lowercased part of the address after the @.
+func EmailDomain(email string) string {
+	if at := strings.LastIndex(email, "@"); at >= 0 {
+		return strings.ToLower(email[at+1:])
+	}
+	return ""
+}
+
+// publicEmailDomains are mail providers anyone can sign up with, so sharing
+// one of them does not make two users part of the same organization.
+var publicEmailDomains = map[string]bool{
+	"gmail.com":      true,
+	"googlemail.com": true,
+	"outlook.com":    true,
+	"hotmail.com":    true,
+	"live.com":       true,
+	"msn.com":        true,
+	"yahoo.com":      true,
+	"ymail.com":      true,
+	"icloud.com":     true,
+	"me.com":         true,
+	"mac.com":        true,
+	"aol.com":        true,
+	"proton.me":      true,
+	"protonmail.com": true,
+	"gmx.com":        true,
+	"mail.com":       true,
+	"zoho.com":       true,
+	"yandex.com":     true,
+}
+
+// OrganizationDomain returns the email domain of the user's organization, or
+// an empty string when the address belongs to a public mail provider.
+func OrganizationDomain(email string) string {
+	domain := EmailDomain(email)
+	if publicEmailDomains[domain] {
+		return ""
+	}
+	return domain
+}
+
 func GetUserInfo(accessToken string) (*models.User, error) {
 	userInfoEndpoint := "https://www.googleapis.com/oauth2/v2/userinfo"
 	resp, err := http.Get(fmt.Sprintf("%s?access_token=%s", userInfoEndpoint, accessToken))
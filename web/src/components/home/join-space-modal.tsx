@@ -8,28 +8,28 @@ import toast, { Toaster } from "react-hot-toast";
 
 const JoinSpaceModal = ({ onSuccess }: { onSuccess: () => void }) => {
   const [isModalOpen, setIsModalOpen] = useState(false);
-  const [spaceId, setSpaceId] = useState<string>("");
+  const [inviteCode, setInviteCode] = useState<string>("");
   const [error, setError] = useState<string | null>(null);
   // const navigate = useNavigate();
 
   const { joinSpace, isLoading, error: apiError } = useApi();
 
   const handleJoinSpace = async () => {
-    const trimmedSpaceId = spaceId.trim();
+    const trimmedCode = inviteCode.trim();
 
-    if (!trimmedSpaceId) {
-      setError("Space ID is required");
+    if (!trimmedCode) {
+      setError("Invite code is required");
       return;
     }
 
     try {
-      await joinSpace(trimmedSpaceId);
+      const response = await joinSpace(trimmedCode);
 
-      setSpaceId("");
+      setInviteCode("");
       setError(null);
       setIsModalOpen(false);
       onSuccess();
-      toast.success(`Joined space #${spaceId}.`);
+      toast.success(`Joined ${response.data?.name ?? "the space"}.`);
       // navigate(0);
     } catch (error) {
       setError(apiError || "Failed to join space");
@@ -39,7 +39,7 @@ const JoinSpaceModal = ({ onSuccess }: { onSuccess: () => void }) => {
   const handleInputChange = (e: React.ChangeEvent<HTMLInputElement>) => {
     if (error) setError(null);
 
-    setSpaceId(e.target.value);
+    setInviteCode(e.target.value);
   };
   return (
     <>
@@ -56,20 +56,20 @@ const JoinSpaceModal = ({ onSuccess }: { onSuccess: () => void }) => {
         onClose={() => {
           setIsModalOpen(false);
           setError(null);
-          setSpaceId("");
+          setInviteCode("");
         }}
         title="Join a space"
         className="text-neutral-800 space-y-4 bg-white"
       >
         <p className="text-sm text-neutral-600">
-          Enter the space ID in the below textarea and click join.
+          Paste the invite code you were given and click join.
         </p>
         <div>
           <input
-            type="number"
-            value={spaceId}
+            type="text"
+            value={inviteCode}
             onChange={handleInputChange}
-            placeholder="ID of the space"
+            placeholder="Invite code"
             className={`border-neutral-300 focus:border-neutral-400 transition-colors ease-in-out [appearance:textfield] [&::-webkit-outer-spin-button]:appearance-none [&::-webkit-inner-spin-button]:appearance-none w-full p-2 outline-none bg-transparent border-2 rounded-lg
               ${error ? "border-red-500 text-red-500" : "border-neutral-200"}`}
           />
@@ -81,7 +81,7 @@ const JoinSpaceModal = ({ onSuccess }: { onSuccess: () => void }) => {
             className="border-neutral-200 border-2"
             onClick={() => {
               setIsModalOpen(false);
-              setSpaceId("");
+              setInviteCode("");
               setError(null);
             }}
             disabled={isLoading}
@@ -91,7 +91,7 @@ const JoinSpaceModal = ({ onSuccess }: { onSuccess: () => void }) => {
           <Button
             variant="dark"
             onClick={handleJoinSpace}
-            disabled={!spaceId.trim() || isLoading}
+            disabled={!inviteCode.trim() || isLoading}
           >
             {isLoading ? <Loader size="small" mode="dark" /> : "Join"}
           </Button>
@@ -5,6 +5,16 @@ import axios from "axios";
 
 const API_BASE_URL = `${import.meta.env.VITE_API_URL}`;
 
+const mapTask = (task: any): TaskType => ({
+  id: task.ID.toString(),
+  status: task.status as TaskStatusType,
+  title: task.title,
+  description: task.description || "",
+  priority: task.priority as "LOW" | "MEDIUM" | "HIGH",
+  createdAt: new Date(task.CreatedAt).toISOString(),
+  dueDate: (task.due_date as string) || "",
+});
+
 export const useKanbanSSE = (spaceId: string) => {
   const [tasks, setTasks] = useState<TaskType[]>([]);
   const [error, setError] = useState<string | null>(null);
@@ -12,27 +22,22 @@ export const useKanbanSSE = (spaceId: string) => {
   useEffect(() => {
     const fetchTasks = async () => {
       try {
-        const response = await axios.get(
-          `${API_BASE_URL}/kanban?spaceID=${spaceId}`
-        );
-        if (response.data.status === "error")
-          throw new Error("Failed to fetch tasks");
-
-        const data = await response.data.data;
-
-        console.log(data);
-
-        const mappedTasks: TaskType[] = data.map((task: any) => ({
-          id: task.ID.toString(),
-          status: task.status as TaskStatusType,
-          title: task.title,
-          description: task.description || "",
-          priority: task.priority as "LOW" | "MEDIUM" | "HIGH",
-          createdAt: new Date(task.CreatedAt).toISOString(),
-          dueDate: (task.due_date as string) || "",
-        }));
-
-        console.log(mappedTasks);
+        // The list is paginated, keep following next_cursor until the last page
+        const mappedTasks: TaskType[] = [];
+        let cursor: string | null = null;
+        do {
+          const params: Record<string, string> = { spaceID: spaceId };
+          if (cursor) params.cursor = cursor;
+
+          const response = await axios.get(`${API_BASE_URL}/kanban`, {
+            params,
+          });
+          if (response.data.status === "error")
+            throw new Error("Failed to fetch tasks");
+
+          mappedTasks.push(...response.data.data.map(mapTask));
+          cursor = response.data.next_cursor ?? null;
+        } while (cursor);
 
         setTasks(mappedTasks);
       } catch (err) {
@@ -42,6 +47,36 @@ export const useKanbanSSE = (spaceId: string) => {
     };
 
     fetchTasks();
+
+    // EventSource resends Last-Event-ID on reconnect so missed changes are replayed
+    const eventSource = new EventSource(
+      `${API_BASE_URL}/kanban/stream?spaceID=${spaceId}`,
+      { withCredentials: true }
+    );
+
+    eventSource.onmessage = (event) => {
+      const { type, data } = JSON.parse(event.data);
+
+      switch (type) {
+        case "task_created":
+        case "task_updated": {
+          const task = mapTask(data);
+          setTasks((prev) =>
+            prev.some((t) => t.id === task.id)
+              ? prev.map((t) => (t.id === task.id ? task : t))
+              : [...prev, task]
+          );
+          break;
+        }
+        case "task_deleted":
+          setTasks((prev) =>
+            prev.filter((t) => t.id !== data.id.toString())
+          );
+          break;
+      }
+    };
+
+    return () => eventSource.close();
   }, [spaceId]);
 
   const createTask = async (task: Omit<TaskType, "id" | "createdAt">) => {
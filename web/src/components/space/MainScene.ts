@@ -9,6 +9,7 @@ class MainScene extends Phaser.Scene {
     ws: WebSocket | null = null;
     lastSentTime: number = 0;
     playersRef!: React.MutableRefObject<Record<string, Player>>;
+    localPositionRef!: React.MutableRefObject<{ x: number; y: number } | null>;
     otherPlayers: Map<string, Phaser.Physics.Arcade.Sprite> = new Map();
     localUserId!: string;
 
@@ -41,12 +42,14 @@ class MainScene extends Phaser.Scene {
     init(data: {
         ws: WebSocket;
         playersRef: React.MutableRefObject<Record<string, Player>>;
+        localPositionRef: React.MutableRefObject<{ x: number; y: number } | null>;
         localUserId: string;
         space: Space;
         onCameraUpdate: (cameraData: { x: number; y: number; zoom: number }) => void;
     }) {
         this.ws = data.ws;
         this.playersRef = data.playersRef;
+        this.localPositionRef = data.localPositionRef;
         this.localUserId = data.localUserId;
         // this.space = data.space;
         this.onCameraUpdate = data.onCameraUpdate;
@@ -184,6 +187,13 @@ class MainScene extends Phaser.Scene {
     update(time: number) {
         if (!this.cursors) return;
 
+        // The server spawned the player or refused their last move
+        if (this.localPositionRef.current) {
+            const { x, y } = this.localPositionRef.current;
+            this.player.setPosition(x, y);
+            this.localPositionRef.current = null;
+        }
+
         this.player.setVelocity(0);
         const speed = 200;
         let moved = false;
@@ -18,6 +18,7 @@ const PhaserSpace = ({ spaceId }: { spaceId: string }) => {
   const [loading, setLoading] = useState(true);
   const {
     playersRef,
+    localPositionRef,
     ws,
     localUserId,
     isConnected,
@@ -70,6 +71,7 @@ const PhaserSpace = ({ spaceId }: { spaceId: string }) => {
     spaceRef.current.scene.start("MainScene", {
       ws,
       playersRef,
+      localPositionRef,
       localUserId,
       // onToggleWhiteboardModal: toggleWhiteboard,
       // onToggleKanbanModal: toggleKanban,
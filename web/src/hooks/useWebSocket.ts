@@ -17,6 +17,8 @@ const useWebSocket = (spaceId: string) => {
     const wsURL = import.meta.env.VITE_WS_URL;
     const wsRef = useRef<WebSocket | null>(null);
     const playersRef = useRef<Record<string, Player>>({});
+    // Position the server places the local player at, picked up by the scene
+    const localPositionRef = useRef<{ x: number, y: number } | null>(null);
     const { user } = useAuth();
     const [isConnected, setIsConnected] = useState(false);
     const [chatHistory, setChatHistory] = useState<ChatHistoryType[]>([]);
@@ -142,6 +144,12 @@ const useWebSocket = (spaceId: string) => {
                     setChatHistory(message.content);
                     break;
                 }
+
+                case "spawn":
+                case "position_corrected": {
+                    localPositionRef.current = message.content.position;
+                    break;
+                }
                 default:
                     console.warn("Unknown message type:", message);
             }
@@ -165,6 +173,7 @@ const useWebSocket = (spaceId: string) => {
     return {
         ws: wsRef.current,
         playersRef,
+        localPositionRef,
         localUserId: user?.id.toString(10),
         isConnected,
         chatHistory,
@@ -9,11 +9,11 @@ export const useApi = () => {
     const [isLoading, setIsLoading] = useState(false);
     const [error, setError] = useState<string | null>(null);
 
-    const joinSpace = async (spaceId: string) => {
+    const joinSpace = async (inviteCode: string) => {
         setIsLoading(true);
         setError(null);
         try {
-            const response = await axios.patch(`${API_BASE_URL}/space/${spaceId}/join`);
+            const response = await axios.post(`${API_BASE_URL}/space/join/${encodeURIComponent(inviteCode)}`);
             // console.log(response.data);
             if (response.data.status == "error") {
                 setError(response.data.status.error)